@@ -27,6 +27,34 @@ type Renderer interface {
 // This function is implemented to be called in in Window.Flush() method.
 type RenderFunc func()
 
+// ViewportRenderFunc is the render function of a viewport, it is called with
+// the camera of the viewport after the viewport is set up.
+type ViewportRenderFunc func(Camera)
+
+// Viewport interface defines a camera rendered to a rectangle of a window.
+type Viewport interface {
+	// SetRect sets the rectangle of the viewport normalized to the window
+	// size, the origin is the bottom-left corner of the window.
+	SetRect(x, y, width, height float32)
+	// GetRect gets the normalized rectangle of the viewport.
+	GetRect() (x, y, width, height float32)
+
+	// SetCamera sets the camera of the viewport, the aspect ratio of the
+	// camera follows the size of the viewport.
+	SetCamera(Camera)
+	GetCamera() Camera
+
+	SetClearColor([4]float32)
+	GetClearColor() [4]float32
+
+	SetRenderFunc(ViewportRenderFunc)
+	GetRenderFunc() ViewportRenderFunc
+
+	// Render sets up the viewport in the framebuffer of the size: width,
+	// height, clears it and calls the render function.
+	Render(width, height int)
+}
+
 // Window interface defines the methods required by a window,
 // each window contains a different OpenGL Context, and the resources of each
 // OpenGL Context is not shared.
@@ -77,6 +105,27 @@ type Window interface {
 	GetTexture(int) Texture
 	GetTextureNum() int
 
+	// SetCamera sets the camera of the window, the aspect ratio of the
+	// camera follows the framebuffer size of the window and the far plane
+	// is set to the view distance of the window.
+	SetCamera(Camera)
+	GetCamera() Camera
+
+	// SetViewDistance sets the far plane of the cameras of the window and
+	// the cameras set later, it is set by the renderer when the window is
+	// appended. 0 keeps the far plane of the cameras.
+	SetViewDistance(float32)
+	GetViewDistance() float32
+
+	// AppendViewport adds a viewport to the window, the viewports are
+	// rendered in the adding order. If the window has viewports, the render
+	// function of the window is not called.
+	AppendViewport(Viewport)
+	GetViewport(int) Viewport
+	GetViewportNum() int
+	// RemoveViewport removes the nth viewport of the window.
+	RemoveViewport(int)
+
 	// Flush renders one frame of window (by calling RenderFunc function)
 	// and updates the current status of window.
 	Flush()
@@ -137,6 +186,34 @@ type Texture interface {
 	SetFileName(string)
 	// GetFileName gets the image file name of texture
 	GetFileName() string
+
+	// Release deletes the OpenGL texture, the texture loaded by the texture
+	// cache is deleted when the last reference is released.
+	Release()
+}
+
+// Material interface defines the methods required by a material,
+// a material references a shader, the uniform values and the textures.
+type Material interface {
+	// SetShader sets the shader program of the material.
+	SetShader(Shader)
+	// GetShader gets the shader program of the material.
+	GetShader() Shader
+
+	// SetUniform sets the uniform value applied when binding the material,
+	// the value types are the same as Shader.Set method.
+	SetUniform(string, interface{})
+	// GetUniform gets the uniform value of the material.
+	GetUniform(string) interface{}
+
+	// SetTexture sets the texture of the named slot (sampler uniform name).
+	SetTexture(string, Texture)
+	// GetTexture gets the texture of the named slot.
+	GetTexture(string) Texture
+
+	// Bind uses the shader program, binds the textures to the texture units
+	// allocated automatically and sets all uniform values.
+	Bind() error
 }
 
 // Camera interface defines the methods required by a Euler angle camera.
@@ -146,15 +223,32 @@ type Camera interface {
 
 	// GetViewMatrix calculate the current view matrix of the camera.
 	GetViewMatrix() glm.Mat4
+	// GetProjectionMatrix calculate the current projection matrix of the
+	// camera, perspective or orthographic.
+	GetProjectionMatrix() glm.Mat4
+	// GetViewProjectionMatrix gets the projection matrix multiplied by the
+	// view matrix.
+	GetViewProjectionMatrix() glm.Mat4
+
+	// SetAspect sets the aspect ratio (width / height) of the projection.
+	SetAspect(float32)
+	// SetNear sets the distance of the near plane of the projection.
+	SetNear(float32)
+	// SetFar sets the distance of the far plane of the projection.
+	SetFar(float32)
+	// SetClipPlanes sets the distances of the near and far planes of the
+	// projection, it returns error if near is not less than far.
+	SetClipPlanes(near, far float32) error
 
 	// GetPosition gets the camera position in the world coordinates.
 	GetPosition() glm.Vec3
 	// SetPosition sets the camera position in the world coordinates.
 	SetPosition(glm.Vec3)
 
-	// GetZoom gets the zoom value of the camera.
+	// GetZoom gets the zoom value (field of view in degrees) of the camera.
 	GetZoom() float32
-	// SetZoom sets the zoom value of the camera.
+	// SetZoom sets the zoom value of the camera, the value is clamped to
+	// the limits of the camera.
 	SetZoom(float32)
 
 	// GetFront gets the front vector of the camera.
@@ -0,0 +1,255 @@
+package camera
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/STARRY-S/aperture"
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/engoengine/glm"
+)
+
+// Bookmark is the named viewpoint of the scene.
+type Bookmark struct {
+	Name        string
+	Position    glm.Vec3
+	Orientation glm.Quat
+	// Zoom is the field of view, 0 means the default zoom.
+	Zoom float32
+}
+
+// bookmarkJSON is the JSON format of the bookmark, the orientation is
+// saved as [x, y, z, w].
+type bookmarkJSON struct {
+	Name        string    `json:"name"`
+	Position    glm.Vec3  `json:"position"`
+	Orientation quatArray `json:"orientation"`
+	Zoom        float32   `json:"zoom,omitempty"`
+}
+
+func (b Bookmark) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bookmarkJSON{
+		Name:        b.Name,
+		Position:    b.Position,
+		Orientation: newQuatArray(b.Orientation),
+		Zoom:        b.Zoom,
+	})
+}
+
+func (b *Bookmark) UnmarshalJSON(data []byte) error {
+	j := bookmarkJSON{}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*b = Bookmark{
+		Name:        j.Name,
+		Position:    j.Position,
+		Orientation: j.Orientation.quat(),
+		Zoom:        j.Zoom,
+	}
+	return nil
+}
+
+// keyframe converts the bookmark to the keyframe at the time.
+func (b Bookmark) keyframe(time float32) Keyframe {
+	return Keyframe{
+		Time:        time,
+		Position:    b.Position,
+		Orientation: b.Orientation,
+		Zoom:        b.Zoom,
+	}
+}
+
+// BookmarksObj stores the named viewpoints of each scene file, the
+// bookmarks of a scene keep the order they were added.
+type BookmarksObj struct {
+	scenes map[string][]Bookmark
+}
+
+// bookmarksJSON is the JSON format of the bookmarks.
+type bookmarksJSON struct {
+	Scenes map[string][]Bookmark `json:"scenes"`
+}
+
+func (b *BookmarksObj) Init() error {
+	if b == nil {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidPointer)
+	}
+	b.scenes = make(map[string][]Bookmark)
+	return nil
+}
+
+// Add saves the current pose of the camera as the bookmark of the scene,
+// the bookmark with the same name is replaced.
+func (b *BookmarksObj) Add(scene, name string, cam aperture.Camera) error {
+	if cam == nil {
+		return fmt.Errorf("Add: %w", utils.ErrInvalidPointer)
+	}
+	if name == "" {
+		return fmt.Errorf("Add: empty name: %w", utils.ErrInvalidParameter)
+	}
+	bm := Bookmark{
+		Name:        name,
+		Position:    cam.GetPosition(),
+		Orientation: getOrientation(cam),
+		Zoom:        cam.GetZoom(),
+	}
+
+	if b.scenes == nil {
+		b.scenes = make(map[string][]Bookmark)
+	}
+	key := sceneKey(scene)
+	list := b.scenes[key]
+	if i := indexOf(list, name); i >= 0 {
+		list[i] = bm
+		return nil
+	}
+	b.scenes[key] = append(list, bm)
+	return nil
+}
+
+// Get gets the bookmark of the scene by name.
+func (b *BookmarksObj) Get(scene, name string) (Bookmark, bool) {
+	list := b.scenes[sceneKey(scene)]
+	if i := indexOf(list, name); i >= 0 {
+		return list[i], true
+	}
+	return Bookmark{}, false
+}
+
+// Remove removes the bookmark of the scene by name.
+func (b *BookmarksObj) Remove(scene, name string) error {
+	key := sceneKey(scene)
+	list := b.scenes[key]
+	i := indexOf(list, name)
+	if i < 0 {
+		return fmt.Errorf("Remove: bookmark %q not found: %w",
+			name, utils.ErrInvalidParameter)
+	}
+	list = append(list[:i], list[i+1:]...)
+	if len(list) == 0 {
+		delete(b.scenes, key)
+	} else {
+		b.scenes[key] = list
+	}
+	return nil
+}
+
+// GetNames gets the names of the bookmarks of the scene in the order they
+// were added.
+func (b *BookmarksObj) GetNames(scene string) []string {
+	list := b.scenes[sceneKey(scene)]
+	names := make([]string, 0, len(list))
+	for _, bm := range list {
+		names = append(names, bm.Name)
+	}
+	return names
+}
+
+// Apply moves the camera to the bookmark of the scene immediately.
+func (b *BookmarksObj) Apply(scene, name string, cam aperture.Camera) error {
+	if cam == nil {
+		return fmt.Errorf("Apply: %w", utils.ErrInvalidPointer)
+	}
+	bm, ok := b.Get(scene, name)
+	if !ok {
+		return fmt.Errorf("Apply: bookmark %q not found: %w",
+			name, utils.ErrInvalidParameter)
+	}
+	cam.SetPosition(bm.Position)
+	setOrientation(cam, bm.Orientation)
+	cam.SetZoom(zoomOf(bm.keyframe(0)))
+	return nil
+}
+
+// AnimateTo creates the track from the current pose of the camera to the
+// bookmark of the scene in the duration in seconds, sample the track with
+// the camera each frame to play the animation.
+func (b *BookmarksObj) AnimateTo(
+	scene, name string, cam aperture.Camera, duration float32, ease Ease,
+) (*TrackObj, error) {
+	if cam == nil {
+		return nil, fmt.Errorf("AnimateTo: %w", utils.ErrInvalidPointer)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("AnimateTo: invalid duration %v: %w",
+			duration, utils.ErrInvalidParameter)
+	}
+	bm, ok := b.Get(scene, name)
+	if !ok {
+		return nil, fmt.Errorf("AnimateTo: bookmark %q not found: %w",
+			name, utils.ErrInvalidParameter)
+	}
+
+	t, err := NewTrackObj(&TrackInitParam{Interpolation: InterpolationLinear})
+	if err != nil {
+		return nil, fmt.Errorf("AnimateTo: %w", err)
+	}
+	if err := t.AddCameraKeyframe(0, cam); err != nil {
+		return nil, fmt.Errorf("AnimateTo: %w", err)
+	}
+	t.keyframes[0].Ease = ease
+	if err := t.AddKeyframe(bm.keyframe(duration)); err != nil {
+		return nil, fmt.Errorf("AnimateTo: %w", err)
+	}
+	return t, nil
+}
+
+func (b *BookmarksObj) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bookmarksJSON{Scenes: b.scenes})
+}
+
+func (b *BookmarksObj) UnmarshalJSON(data []byte) error {
+	j := bookmarksJSON{}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	b.Init()
+	for scene, list := range j.Scenes {
+		key := sceneKey(scene)
+		b.scenes[key] = append(b.scenes[key], list...)
+	}
+	return nil
+}
+
+// Save saves the bookmarks of all scenes to the JSON file.
+func (b *BookmarksObj) Save(file string) error {
+	if err := saveJSON(file, b); err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	return nil
+}
+
+// LoadBookmarks loads the bookmarks from the JSON file.
+func LoadBookmarks(file string) (*BookmarksObj, error) {
+	b := BookmarksObj{}
+	if err := loadJSON(file, &b); err != nil {
+		return nil, fmt.Errorf("LoadBookmarks: %w", err)
+	}
+	return &b, nil
+}
+
+// sceneKey cleans the path of the scene file, so the different spellings
+// of the same file share the bookmarks.
+func sceneKey(scene string) string {
+	if scene == "" {
+		return ""
+	}
+	return filepath.ToSlash(filepath.Clean(scene))
+}
+
+func indexOf(list []Bookmark, name string) int {
+	for i, bm := range list {
+		if bm.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func NewBookmarksObj() (*BookmarksObj, error) {
+	b := &BookmarksObj{}
+	b.Init()
+	return b, nil
+}
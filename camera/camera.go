@@ -22,6 +22,8 @@ type CameraObj struct {
 	speed       float32
 	sensitivity float32
 	zoom        float32
+
+	projection
 }
 
 const (
@@ -31,6 +33,10 @@ const (
 	DirectionRight
 	DirectionUp
 	DirectionDown
+	// DirectionRollLeft and DirectionRollRight are only used by the
+	// cameras able to roll.
+	DirectionRollLeft
+	DirectionRollRight
 )
 
 var (
@@ -58,6 +64,7 @@ func (c *CameraObj) Init() error {
 	c.speed = float32(defaultCameraSpeed)
 	c.sensitivity = float32(defaultCameraSensitivity)
 	c.zoom = float32(defaultCameraZoom)
+	c.initProjection()
 
 	return nil
 }
@@ -67,6 +74,20 @@ func (c *CameraObj) GetViewMatrix() glm.Mat4 {
 	return glm.LookAtV(&c.position, &target, &c.up)
 }
 
+// GetProjectionMatrix gets the projection matrix, the zoom value is the
+// vertical field of view in degrees of the perspective projection.
+func (c *CameraObj) GetProjectionMatrix() glm.Mat4 {
+	return c.matrix(c.zoom)
+}
+
+// GetViewProjectionMatrix gets the projection matrix multiplied by the
+// view matrix.
+func (c *CameraObj) GetViewProjectionMatrix() glm.Mat4 {
+	p := c.GetProjectionMatrix()
+	v := c.GetViewMatrix()
+	return p.Mul4(&v)
+}
+
 func (c *CameraObj) GetPosition() glm.Vec3 {
 	return c.position
 }
@@ -105,8 +126,9 @@ func (c *CameraObj) SetSpeed(s float32) {
 	c.speed = s
 }
 
+// SetZoom sets the zoom value, it is limited between MinZoom and MaxZoom.
 func (c *CameraObj) SetZoom(z float32) {
-	c.zoom = z
+	c.zoom = clampZoom(z)
 }
 
 func (c *CameraObj) ProcessMovement(dt float32, dir int, speedUp float32) {
@@ -154,8 +176,7 @@ func (c *CameraObj) ProcessMouseMove(xOffset, yOffset float32, pitch bool) {
 }
 
 func (c *CameraObj) ProcessScroll(yOffset float32) {
-	c.zoom -= c.sensitivity * yOffset
-	// TODO: limit max zoom & min zoom
+	c.zoom = clampZoom(c.zoom - c.sensitivity*yOffset)
 }
 
 func (c *CameraObj) GetID() uint32 {
@@ -174,7 +195,7 @@ func (c *CameraObj) updateVectors() {
 	sinYaw := math.Sin(float64(glm.DegToRad(c.yaw)))
 	cosYaw := math.Cos(float64(glm.DegToRad(c.yaw)))
 	sinPitch := math.Sin(float64(glm.DegToRad(c.pitch)))
-	cosPitch := math.Sin(float64(glm.DegToRad(c.pitch)))
+	cosPitch := math.Cos(float64(glm.DegToRad(c.pitch)))
 
 	c.front = glm.NormalizeVec3(glm.Vec3{
 		float32(cosYaw * cosPitch),
@@ -1,10 +1,16 @@
 package camera_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"path/filepath"
 	"testing"
 
 	"github.com/STARRY-S/aperture"
 	"github.com/STARRY-S/aperture/camera"
+	"github.com/engoengine/glm"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestInterface(t *testing.T) {
@@ -18,3 +24,602 @@ func TestInterface(t *testing.T) {
 		t.Error("camera id should be 0")
 	}
 }
+
+func TestFront(t *testing.T) {
+	c, _ := camera.NewCameraObj()
+	front := c.GetFront()
+	assert.InDeltaSlice(t, []float32{1, 0, 0}, front[:], 1e-5)
+
+	c.SetPitch(30)
+	front = c.GetFront()
+	assert.InDeltaSlice(t, []float32{0.8660254, 0.5, 0}, front[:], 1e-5)
+
+	c.SetYaw(90)
+	c.SetPitch(0)
+	front = c.GetFront()
+	assert.InDeltaSlice(t, []float32{0, 0, 1}, front[:], 1e-5)
+}
+
+func TestProjection(t *testing.T) {
+	c, _ := camera.NewCameraObj()
+	assert.Equal(t, float32(camera.DefaultFar), c.GetFar())
+
+	c.SetZoom(1000)
+	assert.Equal(t, float32(camera.MaxZoom), c.GetZoom())
+	c.SetZoom(45)
+	c.SetSensitivity(1)
+	c.ProcessScroll(100)
+	assert.Equal(t, float32(camera.MinZoom), c.GetZoom())
+
+	c.SetZoom(90)
+	c.SetAspect(2)
+	c.SetNear(1)
+	c.SetFar(100)
+	p := c.GetProjectionMatrix()
+	expected := glm.Perspective(glm.DegToRad(90), 2, 1, 100)
+	assert.Equal(t, expected, p)
+
+	// the point on the near plane in front of the camera (+X by default)
+	vp := c.GetViewProjectionMatrix()
+	clip := vp.Mul4x1(&glm.Vec4{1, 0, 0, 1})
+	assert.InDelta(t, -1, clip[2]/clip[3], 1e-5)
+
+	c.SetProjectionMode(camera.ProjectionOrthographic)
+	c.SetOrthoSize(4)
+	p = c.GetProjectionMatrix()
+	assert.Equal(t, glm.Ortho(-4, 4, -2, 2, 1, 100), p)
+
+	// invalid values are ignored
+	c.SetAspect(0)
+	c.SetNear(-1)
+	assert.Equal(t, float32(2), c.GetAspect())
+	assert.Equal(t, float32(1), c.GetNear())
+	c.SetNear(100)
+	c.SetFar(0.5)
+	assert.Equal(t, float32(1), c.GetNear())
+	assert.Equal(t, float32(100), c.GetFar())
+
+	// the planes are moved past each other together
+	assert.NoError(t, c.SetClipPlanes(200, 500))
+	assert.Equal(t, float32(200), c.GetNear())
+	assert.Equal(t, float32(500), c.GetFar())
+	assert.Error(t, c.SetClipPlanes(10, 10))
+	assert.Error(t, c.SetClipPlanes(0, 10))
+	assert.Equal(t, float32(200), c.GetNear())
+}
+
+func TestOrbit(t *testing.T) {
+	c, err := camera.NewOrbitCameraObj()
+	if err != nil {
+		t.Error(err.Error())
+	}
+	var cam aperture.Camera = c
+	cam.SetSensitivity(1)
+
+	pos := c.GetPosition()
+	assert.InDeltaSlice(t, []float32{5, 0, 0}, pos[:], 1e-5)
+	front := c.GetFront()
+	assert.InDeltaSlice(t, []float32{-1, 0, 0}, front[:], 1e-5)
+
+	c.SetPosition(glm.Vec3{0, 3, 4})
+	assert.InDelta(t, 5, c.GetDistance(), 1e-5)
+	pos = c.GetPosition()
+	assert.InDeltaSlice(t, []float32{0, 3, 4}, pos[:], 1e-5)
+
+	// the elevation is limited
+	cam.ProcessMouseMove(0, -1000, false)
+	assert.InDelta(t, 89.9, c.GetElevation(), 1e-5)
+
+	// the target is projected to the center of the screen
+	c.SetElevation(30)
+	c.SetTarget(glm.Vec3{1, 2, 3})
+	vp := c.GetViewProjectionMatrix()
+	clip := vp.Mul4x1(&glm.Vec4{1, 2, 3, 1})
+	assert.InDelta(t, 0, clip[0]/clip[3], 1e-5)
+	assert.InDelta(t, 0, clip[1]/clip[3], 1e-5)
+
+	c.SetDistanceLimits(2, 10)
+	cam.ProcessScroll(100)
+	assert.Equal(t, float32(2), c.GetDistance())
+	cam.ProcessScroll(-100)
+	assert.Equal(t, float32(10), c.GetDistance())
+
+	c.Pan(1, 0)
+	target := c.GetTarget()
+	right := c.GetRight()
+	moved := target.Sub(&glm.Vec3{1, 2, 3})
+	assert.InDelta(t, 1, moved.Dot(&right), 1e-5)
+
+	// the point under the cursor stays under the cursor
+	c.SetAspect(1.5)
+	vp = c.GetViewProjectionMatrix()
+	inv := vp.Inverse()
+	p := glm.Vec4{0.5, -0.25, 0.5, 1}
+	world := inv.Mul4x1(&p)
+	world = world.Mul(1 / world[3])
+	c.ZoomToCursor(0.5, -0.25, 3)
+	vp = c.GetViewProjectionMatrix()
+	clip = vp.Mul4x1(&world)
+	assert.InDelta(t, 0.5, clip[0]/clip[3], 1e-4)
+	assert.InDelta(t, -0.25, clip[1]/clip[3], 1e-4)
+
+	// all corners of the framed box are in the view
+	c.SetDistanceLimits(0.01, 0)
+	min, max := glm.Vec3{-1, -2, -3}, glm.Vec3{3, 4, 5}
+	c.FrameBox(min, max)
+	vp = c.GetViewProjectionMatrix()
+	for i := 0; i < 8; i++ {
+		corner := glm.Vec4{min[0], min[1], min[2], 1}
+		for j := 0; j < 3; j++ {
+			if i&(1<<j) != 0 {
+				corner[j] = max[j]
+			}
+		}
+		clip = vp.Mul4x1(&corner)
+		for j := 0; j < 2; j++ {
+			ndc := clip[j] / clip[3]
+			assert.True(t, ndc >= -1 && ndc <= 1, "corner %v out of view", corner)
+		}
+	}
+
+	// world up other than +Y
+	c.SetUp(glm.Vec3{0, 0, 1})
+	c.SetPosition(glm.Vec3{1, 2, 10})
+	pos = c.GetPosition()
+	assert.InDeltaSlice(t, []float32{1, 2, 10}, pos[:], 1e-4)
+}
+
+func TestQuaternion(t *testing.T) {
+	c, err := camera.NewQuatCameraObj()
+	if err != nil {
+		t.Error(err.Error())
+	}
+	var cam aperture.Camera = c
+
+	// same default direction as the Euler angle camera
+	front := c.GetFront()
+	assert.InDeltaSlice(t, []float32{1, 0, 0}, front[:], 1e-5)
+
+	cam.SetYaw(90)
+	cam.SetPitch(30)
+	c.SetRoll(20)
+	assert.InDelta(t, 90, c.GetYaw(), 1e-3)
+	assert.InDelta(t, 30, c.GetPitch(), 1e-3)
+	assert.InDelta(t, 20, c.GetRoll(), 1e-3)
+	front = c.GetFront()
+	assert.InDeltaSlice(t, []float32{0, 0.5, 0.866025}, front[:], 1e-5)
+
+	// the view matrix transforms the front vector to -Z
+	c.SetPosition(glm.Vec3{1, 2, 3})
+	v := c.GetViewMatrix()
+	p := c.GetPosition()
+	p.AddScaledVec(2, &front)
+	view := v.Mul4x1(&glm.Vec4{p[0], p[1], p[2], 1})
+	assert.InDeltaSlice(t, []float32{0, 0, -2, 1}, view[:], 1e-5)
+
+	// no pitch limit when rotating freely
+	c.SetRoll(0)
+	c.SetPitch(0)
+	c.SetSensitivity(1)
+	cam.ProcessMouseMove(0, 120, false)
+	up := c.GetUp()
+	assert.True(t, up.Y() < 0, "camera should be upside down")
+	cam.ProcessMouseMove(0, -240, true)
+	assert.InDelta(t, -89.9, c.GetPitch(), 1e-3)
+
+	c.SetRollSpeed(90)
+	c.SetPitch(0)
+	cam.ProcessMovement(0.5, camera.DirectionRollRight, 1)
+	assert.InDelta(t, 45, c.GetRoll(), 1e-3)
+
+	c.LookAt(glm.Vec3{1, 2, 10})
+	front = c.GetFront()
+	assert.InDeltaSlice(t, []float32{0, 0, 1}, front[:], 1e-5)
+	assert.InDelta(t, 0, c.GetRoll(), 1e-3)
+
+	from := c.GetOrientation()
+	c.SetYaw(-90)
+	to := c.GetOrientation()
+	half := camera.Slerp(from, to, 0.5)
+	c.SetOrientation(half)
+	front = c.GetFront()
+	assert.InDelta(t, 0, front.Z(), 1e-5)
+	c.SlerpTo(to, 1)
+	front = c.GetFront()
+	assert.InDeltaSlice(t, []float32{0, 0, -1}, front[:], 1e-5)
+
+	// arbitrary world up vector
+	c.SetUp(glm.Vec3{0, 0, 1})
+	c.SetPitch(0)
+	c.SetRoll(0)
+	up = c.GetUp()
+	assert.InDeltaSlice(t, []float32{0, 0, 1}, up[:], 1e-5)
+}
+
+func TestTrack(t *testing.T) {
+	track, err := camera.NewTrackObj(nil)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	_, err = track.Evaluate(0)
+	assert.NotNil(t, err)
+	_, err = camera.NewTrackObj(&camera.TrackInitParam{Interpolation: "unknown"})
+	assert.NotNil(t, err)
+
+	q := glm.QuatIdent()
+	turn := glm.QuatRotate(glm.DegToRad(90), &glm.Vec3{0, 1, 0})
+	keys := []camera.Keyframe{
+		{Time: 2, Position: glm.Vec3{2, 0, 0}, Orientation: turn, Zoom: 90},
+		{Time: 0, Position: glm.Vec3{0, 0, 0}, Orientation: q, Zoom: 30},
+		{Time: 1, Position: glm.Vec3{1, 1, 0}, Orientation: q, Zoom: 60,
+			Ease: camera.EaseInOut},
+		{Time: 3, Position: glm.Vec3{3, 1, 0}, Orientation: turn, Zoom: 90},
+	}
+	for _, k := range keys {
+		assert.Nil(t, track.AddKeyframe(k))
+	}
+	assert.Equal(t, 4, track.GetKeyframeCount())
+	assert.Equal(t, float32(3), track.GetDuration())
+	assert.Equal(t, float32(1), track.GetKeyframes()[1].Time)
+
+	// the curves pass through the keyframes
+	for _, i := range []camera.Interpolation{
+		camera.InterpolationLinear,
+		camera.InterpolationCatmullRom,
+		camera.InterpolationBezier,
+	} {
+		assert.Nil(t, track.SetInterpolation(i))
+		k, err := track.Evaluate(1)
+		assert.Nil(t, err)
+		assert.InDeltaSlice(t, []float32{1, 1, 0}, k.Position[:], 1e-5)
+		assert.Equal(t, float32(60), k.Zoom)
+	}
+
+	// the ease curve of the second keyframe
+	k, _ := track.Evaluate(1.25)
+	assert.InDelta(t, 60+30*0.15625, k.Zoom, 1e-4)
+	front := k.Orientation.Rotate(&glm.Vec3{0, 0, -1})
+	assert.InDelta(t, 0.15625*math.Pi/2, math.Atan2(-float64(front.X()), -float64(front.Z())), 1e-4)
+
+	// clamped and looped time
+	k, _ = track.Evaluate(10)
+	assert.InDeltaSlice(t, []float32{3, 1, 0}, k.Position[:], 1e-5)
+	track.SetLoop(true)
+	k, _ = track.Evaluate(4)
+	assert.InDeltaSlice(t, []float32{1, 1, 0}, k.Position[:], 1e-5)
+	k, _ = track.Evaluate(-2)
+	assert.InDeltaSlice(t, []float32{1, 1, 0}, k.Position[:], 1e-5)
+
+	// the closed loop is smooth across the seam
+	square, _ := camera.NewTrackObj(nil)
+	for i, p := range []glm.Vec3{{0, 0, 0}, {4, 0, 0}, {4, 0, 4}, {0, 0, 4}} {
+		square.AddKeyframe(camera.Keyframe{Time: float32(i), Position: p, Orientation: q})
+	}
+	assert.NotNil(t, square.CloseLoop(3))
+	assert.Nil(t, square.CloseLoop(4))
+	assert.Equal(t, 5, square.GetKeyframeCount())
+	square.SetLoop(true)
+	velocity := func(time float32) glm.Vec3 {
+		a, _ := square.Evaluate(time - 1e-3)
+		b, _ := square.Evaluate(time + 1e-3)
+		return b.Position.Sub(&a.Position)
+	}
+	before, after := velocity(4-2e-3), velocity(2e-3)
+	assert.InDeltaSlice(t, before[:], after[:], 1e-3)
+	k, _ = square.Evaluate(4.5)
+	k2, _ := square.Evaluate(0.5)
+	assert.InDeltaSlice(t, k2.Position[:], k.Position[:], 1e-5)
+
+	// the explicit Bézier handles
+	track.SetInterpolation(camera.InterpolationBezier)
+	zero := glm.Vec3{}
+	track.AddKeyframe(camera.Keyframe{Time: 0, Orientation: q, OutHandle: &zero})
+	keys[2].InHandle = &zero
+	track.AddKeyframe(keys[2])
+	k, _ = track.Evaluate(0.5)
+	assert.InDeltaSlice(t, []float32{0.5, 0.5, 0}, k.Position[:], 1e-5)
+
+	// drive the cameras
+	euler, _ := camera.NewCameraObj()
+	quat, _ := camera.NewQuatCameraObj()
+	for _, cam := range []aperture.Camera{euler, quat} {
+		assert.Nil(t, track.Sample(2, cam))
+		pos := cam.GetPosition()
+		assert.InDeltaSlice(t, []float32{2, 0, 0}, pos[:], 1e-5)
+		assert.Equal(t, float32(90), cam.GetZoom())
+	}
+	for _, cam := range []aperture.Camera{euler, quat} {
+		front = cam.GetFront()
+		assert.InDeltaSlice(t, []float32{-1, 0, 0}, front[:], 1e-5)
+	}
+
+	track.Clear()
+	assert.Nil(t, track.AddCameraKeyframe(0, quat))
+	assert.Nil(t, track.AddCameraKeyframe(1, euler))
+	assert.NotNil(t, track.RemoveKeyframe(2))
+
+	file := filepath.Join(t.TempDir(), "track.json")
+	assert.Nil(t, track.Save(file))
+	loaded, err := camera.LoadTrack(file)
+	assert.Nil(t, err)
+	assert.Equal(t, track.GetInterpolation(), loaded.GetInterpolation())
+	assert.Equal(t, track.IsLoop(), loaded.IsLoop())
+	assert.Equal(t, track.GetKeyframes(), loaded.GetKeyframes())
+}
+
+func TestSmooth(t *testing.T) {
+	_, err := camera.NewSmoothCameraObj(nil)
+	assert.NotNil(t, err)
+
+	// hold the forward key for one second and release it for one second,
+	// the motion is the same at different frame rates
+	simulate := func(fps int) (*camera.SmoothCameraObj, *camera.CameraObj) {
+		c, _ := camera.NewCameraObj()
+		s, _ := camera.NewSmoothCameraObj(c)
+		var cam aperture.Camera = s
+		dt := 1 / float32(fps)
+		for i := 0; i < 2*fps; i++ {
+			if i < fps {
+				cam.ProcessMovement(dt, camera.DirectionForward, 2)
+			}
+			if i == 0 {
+				cam.ProcessMouseMove(100, 0, true)
+				cam.ProcessScroll(-500)
+			}
+			s.Update(dt)
+		}
+		return s, c
+	}
+	s30, c30 := simulate(30)
+	_, c240 := simulate(240)
+	p30, p240 := c30.GetPosition(), c240.GetPosition()
+	assert.InDelta(t, p30.X(), p240.X(), 1e-3)
+	assert.InDelta(t, 0, p30.Y(), 1e-5)
+	assert.InDelta(t, c30.GetZoom(), c240.GetZoom(), 1e-3)
+	// the camera keeps moving after the key released but not too far
+	assert.True(t, p30.X() > 2*0.8 && p30.X() < 2*1.3, "unexpected distance %v", p30.X())
+	v := s30.GetVelocity()
+	assert.InDelta(t, 0, v.X(), 1e-3)
+
+	// the mouse-look is smoothed but fully applied
+	f30, f240 := c30.GetFront(), c240.GetFront()
+	assert.InDeltaSlice(t, f30[:], f240[:], 1e-4)
+	expected, _ := camera.NewCameraObj()
+	expected.ProcessMouseMove(100, 0, true)
+	fe := expected.GetFront()
+	assert.InDeltaSlice(t, fe[:], f30[:], 1e-4)
+
+	// no smoothing applies the input instantly
+	c, _ := camera.NewCameraObj()
+	s, _ := camera.NewSmoothCameraObj(c)
+	s.SetAcceleration(0)
+	s.SetDeceleration(0)
+	s.SetLookSmoothing(0)
+	s.ProcessMovement(0.5, camera.DirectionLeft, 1)
+	s.Update(0.5)
+	p := c.GetPosition()
+	assert.InDelta(t, -0.5, p.Z(), 1e-5)
+	s.Update(0.5)
+	p = c.GetPosition()
+	assert.InDelta(t, -0.5, p.Z(), 1e-5)
+
+	value, distance := camera.Damp(0, 10, 1, 1)
+	assert.InDelta(t, 10*(1-math.Exp(-1)), value, 1e-5)
+	assert.InDelta(t, 10*math.Exp(-1), distance, 1e-5)
+}
+
+func TestFollow(t *testing.T) {
+	c, err := camera.NewFollowCameraObj()
+	if err != nil {
+		t.Error(err.Error())
+	}
+	var cam aperture.Camera = c
+
+	// behind and above the target looking at -Z
+	pos := c.GetPosition()
+	assert.InDeltaSlice(t, []float32{0, 2, 5}, pos[:], 1e-5)
+
+	// the camera lags behind the moving target and catches up
+	c.SetTarget(glm.Vec3{0, 0, -10}, glm.QuatIdent())
+	c.Update(1.0 / 60)
+	pos = c.GetPosition()
+	assert.True(t, pos.Z() > -5+0.5, "camera should lag behind, got %v", pos)
+	for i := 0; i < 120; i++ {
+		c.Update(1.0 / 60)
+	}
+	pos = c.GetPosition()
+	assert.InDeltaSlice(t, []float32{0, 2, -5}, pos[:], 1e-3)
+
+	// the critically damped spring does not overshoot
+	c.SetTarget(glm.Vec3{0, 0, -20}, glm.QuatIdent())
+	minZ := float32(0)
+	for i := 0; i < 240; i++ {
+		c.Update(1.0 / 120)
+		pos = c.GetPosition()
+		if pos.Z() < minZ {
+			minZ = pos.Z()
+		}
+	}
+	assert.True(t, minZ >= -15-1e-3, "camera overshoots to %v", minZ)
+
+	// the target turning around, the camera swings behind it
+	turn := glm.QuatRotate(glm.DegToRad(180), &glm.Vec3{0, 1, 0})
+	c.SetTarget(glm.Vec3{}, turn)
+	c.Snap()
+	pos = c.GetPosition()
+	assert.InDeltaSlice(t, []float32{0, 2, -5}, pos[:], 1e-4)
+	front := cam.GetFront()
+	assert.True(t, front.Z() > 0)
+
+	// the obstruction shortens the arm
+	c.SetTarget(glm.Vec3{}, glm.QuatIdent())
+	c.SetObstruction(func(from, to glm.Vec3) (float32, bool) {
+		return 2, true
+	})
+	c.SetProbeMargin(0.5)
+	c.Snap()
+	pos = c.GetPosition()
+	pivot := glm.Vec3{0, 1, 0}
+	arm := pos.Sub(&pivot)
+	assert.InDelta(t, 1.5, arm.Len(), 1e-5)
+	c.SetObstruction(nil)
+
+	// look ahead of the moving target
+	c.SetLookAhead(1)
+	for i := 0; i < 120; i++ {
+		c.SetTarget(glm.Vec3{float32(i+1) / 60, 0, 0}, glm.QuatIdent())
+		c.Update(1.0 / 60)
+	}
+	front = cam.GetFront()
+	assert.True(t, front.X() > 0.1, "camera should look ahead, got %v", front)
+
+	// mouse rotates the arm, scroll scales it
+	c.SetLookAhead(0)
+	c.SetTarget(glm.Vec3{}, glm.QuatIdent())
+	c.SetSensitivity(1)
+	cam.ProcessMouseMove(90, 0, true)
+	cam.ProcessScroll(-1)
+	c.Snap()
+	front = cam.GetFront()
+	assert.True(t, front.X() > 0.9, "view should turn right, got %v", front)
+	pos = c.GetPosition()
+	arm = pos.Sub(&pivot)
+	assert.InDelta(t, math.Sqrt(1+25)/0.9, arm.Len(), 1e-4)
+
+	// the arm scale is limited
+	cam.ProcessScroll(-1000)
+	assert.Equal(t, float32(10), c.GetArmScale())
+	cam.ProcessScroll(1000)
+	assert.Equal(t, float32(0.1), c.GetArmScale())
+	c.SetArmScale(1)
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	data = bytes.Replace(data, []byte(`"arm_scale":1`), []byte(`"arm_scale":0`), 1)
+	if err := json.Unmarshal(data, c); err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, float32(0.1), c.GetArmScale())
+	c.SetArmScale(1)
+
+	// the long frame snaps the camera instead of simulating the spring
+	c.SetTarget(glm.Vec3{100, 0, 0}, glm.QuatIdent())
+	c.Update(1000)
+	snapped := c.GetPosition()
+	c.Snap()
+	pos = c.GetPosition()
+	assert.Equal(t, pos, snapped)
+}
+
+func TestState(t *testing.T) {
+	c, _ := camera.NewCameraObj()
+	c.SetName("main")
+	c.SetPosition(glm.Vec3{1, 2, 3})
+	c.SetYaw(30)
+	c.SetPitch(-10)
+	c.SetZoom(50)
+	c.SetProjectionMode(camera.ProjectionOrthographic)
+	c.SetOrthoSize(8)
+	data, err := json.Marshal(c)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"mode":"orthographic"`)
+
+	c2 := &camera.CameraObj{}
+	assert.Nil(t, json.Unmarshal(data, c2))
+	assert.Equal(t, "main", c2.GetName())
+	assert.Equal(t, c.GetPosition(), c2.GetPosition())
+	assert.Equal(t, c.GetFront(), c2.GetFront())
+	assert.Equal(t, c.GetProjectionMatrix(), c2.GetProjectionMatrix())
+
+	// the missing fields keep the current values
+	assert.Nil(t, json.Unmarshal([]byte(`{"zoom": 40}`), c2))
+	assert.Equal(t, float32(40), c2.GetZoom())
+	assert.Equal(t, c.GetPosition(), c2.GetPosition())
+	assert.NotNil(t, json.Unmarshal([]byte(`{"projection": {"mode": "fisheye"}}`), c2))
+
+	o, _ := camera.NewOrbitCameraObj()
+	o.SetTarget(glm.Vec3{1, 0, 0})
+	o.SetDistanceLimits(2, 20)
+	o.SetDistance(5)
+	o.SetAzimuth(45)
+	o.SetElevation(30)
+	data, _ = json.Marshal(o)
+	o2 := &camera.OrbitCameraObj{}
+	assert.Nil(t, json.Unmarshal(data, o2))
+	assert.Equal(t, o.GetViewMatrix(), o2.GetViewMatrix())
+
+	q, _ := camera.NewQuatCameraObj()
+	q.SetPosition(glm.Vec3{0, 1, 0})
+	q.Rotate(20, 10, 5)
+	data, _ = json.Marshal(q)
+	q2 := &camera.QuatCameraObj{}
+	assert.Nil(t, json.Unmarshal(data, q2))
+	m1, m2 := q.GetViewMatrix(), q2.GetViewMatrix()
+	assert.InDeltaSlice(t, m1[:], m2[:], 1e-5)
+
+	f, _ := camera.NewFollowCameraObj()
+	f.SetOffset(glm.Vec3{0, 3, 6})
+	f.SetSpring(30, 0.5)
+	f.SetYaw(15)
+	f.Snap()
+	data, _ = json.Marshal(f)
+	f2 := &camera.FollowCameraObj{}
+	assert.Nil(t, json.Unmarshal(data, f2))
+	assert.Equal(t, f.GetOffset(), f2.GetOffset())
+	assert.Equal(t, f.GetPosition(), f2.GetPosition())
+
+	// the smoothing camera saves the wrapped camera
+	s, _ := camera.NewSmoothCameraObj(c2)
+	data, err = json.Marshal(s)
+	assert.Nil(t, err)
+	c3 := &camera.CameraObj{}
+	assert.Nil(t, json.Unmarshal(data, c3))
+	assert.Equal(t, c2.GetPosition(), c3.GetPosition())
+}
+
+func TestBookmarks(t *testing.T) {
+	b, _ := camera.NewBookmarksObj()
+	c, _ := camera.NewCameraObj()
+	c.SetPosition(glm.Vec3{1, 2, 3})
+	c.SetYaw(90)
+	assert.Nil(t, b.Add("scenes/level1.json", "door", c))
+	c.SetPosition(glm.Vec3{-4, 0, 0})
+	c.SetYaw(0)
+	assert.Nil(t, b.Add("scenes/level1.json", "hall", c))
+	assert.NotNil(t, b.Add("scenes/level1.json", "", c))
+	assert.Equal(t, []string{"door", "hall"}, b.GetNames("./scenes/level1.json"))
+	assert.Empty(t, b.GetNames("scenes/level2.json"))
+
+	assert.Nil(t, b.Apply("scenes/level1.json", "door", c))
+	pos := c.GetPosition()
+	assert.InDeltaSlice(t, []float32{1, 2, 3}, pos[:], 1e-5)
+	front := c.GetFront()
+	assert.InDeltaSlice(t, []float32{0, 0, 1}, front[:], 1e-5)
+	assert.NotNil(t, b.Apply("scenes/level1.json", "roof", c))
+
+	// animate from the door to the hall
+	track, err := b.AnimateTo("scenes/level1.json", "hall", c, 2, camera.EaseInOut)
+	assert.Nil(t, err)
+	assert.Equal(t, float32(2), track.GetDuration())
+	assert.Nil(t, track.Sample(1, c))
+	pos = c.GetPosition()
+	assert.InDeltaSlice(t, []float32{-1.5, 1, 1.5}, pos[:], 1e-4)
+	assert.Nil(t, track.Sample(2, c))
+	pos = c.GetPosition()
+	assert.InDeltaSlice(t, []float32{-4, 0, 0}, pos[:], 1e-4)
+	_, err = b.AnimateTo("scenes/level1.json", "hall", c, 0, camera.EaseLinear)
+	assert.NotNil(t, err)
+
+	file := filepath.Join(t.TempDir(), "bookmarks.json")
+	assert.Nil(t, b.Save(file))
+	loaded, err := camera.LoadBookmarks(file)
+	assert.Nil(t, err)
+	bm, ok := loaded.Get("scenes/level1.json", "door")
+	assert.True(t, ok)
+	assert.InDeltaSlice(t, []float32{1, 2, 3}, bm.Position[:], 1e-5)
+
+	assert.Nil(t, loaded.Remove("scenes/level1.json", "door"))
+	assert.Equal(t, []string{"hall"}, loaded.GetNames("scenes/level1.json"))
+	assert.NotNil(t, loaded.Remove("scenes/level1.json", "door"))
+}
@@ -0,0 +1,383 @@
+package camera
+
+import (
+	"math"
+
+	"github.com/engoengine/glm"
+)
+
+// ObstructionFunc tests the segment from the pivot on the target to the
+// camera position, it returns the distance from the pivot to the first
+// obstruction and true if the segment is blocked.
+type ObstructionFunc func(from, to glm.Vec3) (float32, bool)
+
+// FollowCameraObj is the third-person camera following the target at the
+// end of a spring arm.
+//
+// The arm starts at the pivot (the target position plus the look offset)
+// and ends at the desired camera position (the target position plus the
+// offset), both offsets are in the local space of the target which looks
+// at -Z with +Y up. The camera is pulled to the desired position by a
+// spring-damper and looks at the pivot moved ahead by the target velocity.
+type FollowCameraObj struct {
+	id   uint32
+	name string
+
+	position glm.Vec3
+	velocity glm.Vec3
+	look     glm.Vec3
+	worldUp  glm.Vec3
+
+	targetPosition    glm.Vec3
+	targetOrientation glm.Quat
+	targetVelocity    glm.Vec3
+	lastTarget        glm.Vec3
+
+	offset     glm.Vec3
+	lookOffset glm.Vec3
+	armScale   float32
+	// armYaw and armPitch rotate the arm around the pivot by the mouse
+	armYaw   float32
+	armPitch float32
+
+	stiffness    float32
+	dampingRatio float32
+	lookAhead    float32
+	obstruction  ObstructionFunc
+	probeMargin  float32
+
+	speed       float32
+	sensitivity float32
+	zoom        float32
+
+	projection
+}
+
+var (
+	defaultFollowOffset       = glm.Vec3{0.0, 2.0, 5.0}
+	defaultFollowLookOffset   = glm.Vec3{0.0, 1.0, 0.0}
+	defaultFollowStiffness    = 60.0
+	defaultFollowDamping      = 1.0
+	defaultFollowProbeMargin  = 0.2
+	defaultFollowSensitivity  = 0.2
+	defaultFollowVelocityRate = 10.0
+
+	// minArmLength keeps the camera out of the pivot when the arm is
+	// fully blocked.
+	minArmLength = 0.1
+	// maxFollowStep is the maximum time step of the spring simulation.
+	maxFollowStep = 1.0 / 240.0
+	// maxFollowDelta is the maximum delta time of Update, the camera snaps
+	// to the end of the arm after the longer frames (loading, breakpoint).
+	maxFollowDelta = 0.25
+	// minArmScale and maxArmScale are the limits of the arm scale changed
+	// by the scroll.
+	minArmScale = 0.1
+	maxArmScale = 10.0
+)
+
+func (c *FollowCameraObj) Init() error {
+	c.worldUp = defaultCameraUp
+	c.targetPosition = glm.Vec3{}
+	c.targetOrientation = glm.QuatIdent()
+	c.targetVelocity = glm.Vec3{}
+	c.lastTarget = c.targetPosition
+	c.offset = defaultFollowOffset
+	c.lookOffset = defaultFollowLookOffset
+	c.armScale = 1
+	c.armYaw, c.armPitch = 0, 0
+	c.stiffness = float32(defaultFollowStiffness)
+	c.dampingRatio = float32(defaultFollowDamping)
+	c.lookAhead = 0
+	c.obstruction = nil
+	c.probeMargin = float32(defaultFollowProbeMargin)
+	c.speed = float32(defaultCameraSpeed)
+	c.sensitivity = float32(defaultFollowSensitivity)
+	c.zoom = float32(defaultCameraZoom)
+	c.initProjection()
+	c.Snap()
+
+	return nil
+}
+
+// Snap moves the camera to the end of the arm immediately without the
+// spring lag, it is used after teleporting the target.
+func (c *FollowCameraObj) Snap() {
+	c.lastTarget = c.targetPosition
+	c.targetVelocity = glm.Vec3{}
+	c.velocity = glm.Vec3{}
+	c.position = c.probe(c.pivot(), c.desired())
+	c.look = c.pivot()
+}
+
+// SetTarget sets the position and orientation of the followed target, the
+// camera follows it by Update.
+func (c *FollowCameraObj) SetTarget(position glm.Vec3, orientation glm.Quat) {
+	c.targetPosition = position
+	if orientation.Len() != 0 {
+		c.targetOrientation = orientation.Normalized()
+	}
+}
+
+func (c *FollowCameraObj) GetTargetPosition() glm.Vec3 {
+	return c.targetPosition
+}
+
+func (c *FollowCameraObj) GetTargetOrientation() glm.Quat {
+	return c.targetOrientation
+}
+
+// SetOffset sets the desired camera position in the local space of the
+// target, the default is behind and above the target.
+func (c *FollowCameraObj) SetOffset(offset glm.Vec3) {
+	c.offset = offset
+}
+
+func (c *FollowCameraObj) GetOffset() glm.Vec3 {
+	return c.offset
+}
+
+// SetLookOffset sets the pivot the camera looking at in the local space of
+// the target.
+func (c *FollowCameraObj) SetLookOffset(offset glm.Vec3) {
+	c.lookOffset = offset
+}
+
+func (c *FollowCameraObj) GetLookOffset() glm.Vec3 {
+	return c.lookOffset
+}
+
+// SetSpring sets the stiffness (1/s²) of the spring and the damping ratio,
+// the ratio 1 is critically damped, less than 1 overshoots. The stiffness
+// less than or equal to 0 disables the lag.
+func (c *FollowCameraObj) SetSpring(stiffness, dampingRatio float32) {
+	c.stiffness = stiffness
+	c.dampingRatio = max32(dampingRatio, 0)
+}
+
+func (c *FollowCameraObj) GetSpring() (stiffness, dampingRatio float32) {
+	return c.stiffness, c.dampingRatio
+}
+
+// SetLookAhead sets the time in seconds of the target velocity added to
+// the look point, so the camera looks where the target is going.
+func (c *FollowCameraObj) SetLookAhead(t float32) {
+	c.lookAhead = t
+}
+
+func (c *FollowCameraObj) GetLookAhead() float32 {
+	return c.lookAhead
+}
+
+// SetObstruction sets the obstruction test of the arm, the arm is
+// shortened to the obstruction minus the probe margin. Set nil to disable.
+func (c *FollowCameraObj) SetObstruction(f ObstructionFunc) {
+	c.obstruction = f
+}
+
+// SetProbeMargin sets the distance the camera keeps from the obstruction.
+func (c *FollowCameraObj) SetProbeMargin(margin float32) {
+	c.probeMargin = max32(margin, 0)
+}
+
+// Update moves the camera towards the end of the arm, it should be called
+// once per frame after the target moved with the delta time in seconds.
+// The delta time longer than 0.25 seconds snaps the camera to the arm.
+func (c *FollowCameraObj) Update(dt float32) {
+	if dt <= 0 {
+		return
+	}
+	if dt > float32(maxFollowDelta) {
+		c.Snap()
+		return
+	}
+
+	moved := c.targetPosition.Sub(&c.lastTarget)
+	c.lastTarget = c.targetPosition
+	c.targetVelocity = DampVec3(c.targetVelocity, moved.Mul(1/dt),
+		float32(defaultFollowVelocityRate), dt)
+
+	desired := c.desired()
+	if c.stiffness <= 0 {
+		c.position = desired
+		c.velocity = glm.Vec3{}
+	} else {
+		damping := 2 * c.dampingRatio * float32(math.Sqrt(float64(c.stiffness)))
+		// the semi-implicit Euler steps are stable for the small steps
+		steps := int(math.Ceil(float64(dt / float32(maxFollowStep))))
+		h := dt / float32(steps)
+		for i := 0; i < steps; i++ {
+			d := desired.Sub(&c.position)
+			var a glm.Vec3
+			a.AddScaledVec(c.stiffness, &d)
+			a.AddScaledVec(-damping, &c.velocity)
+			c.velocity.AddScaledVec(h, &a)
+			c.position.AddScaledVec(h, &c.velocity)
+		}
+	}
+
+	pivot := c.pivot()
+	c.position = c.probe(pivot, c.position)
+	c.look = pivot
+	c.look.AddScaledVec(c.lookAhead, &c.targetVelocity)
+}
+
+func (c *FollowCameraObj) GetViewMatrix() glm.Mat4 {
+	return glm.LookAtV(&c.position, &c.look, &c.worldUp)
+}
+
+// GetProjectionMatrix gets the projection matrix, the zoom value is the
+// vertical field of view in degrees of the perspective projection.
+func (c *FollowCameraObj) GetProjectionMatrix() glm.Mat4 {
+	return c.matrix(c.zoom)
+}
+
+// GetViewProjectionMatrix gets the projection matrix multiplied by the
+// view matrix.
+func (c *FollowCameraObj) GetViewProjectionMatrix() glm.Mat4 {
+	p := c.GetProjectionMatrix()
+	v := c.GetViewMatrix()
+	return p.Mul4(&v)
+}
+
+func (c *FollowCameraObj) GetPosition() glm.Vec3 {
+	return c.position
+}
+
+// SetPosition moves the camera, it is pulled back to the arm by Update.
+func (c *FollowCameraObj) SetPosition(pos glm.Vec3) {
+	c.position = pos
+	c.velocity = glm.Vec3{}
+}
+
+func (c *FollowCameraObj) GetZoom() float32 {
+	return c.zoom
+}
+
+// SetZoom sets the zoom value, it is limited between MinZoom and MaxZoom.
+func (c *FollowCameraObj) SetZoom(z float32) {
+	c.zoom = clampZoom(z)
+}
+
+// GetFront gets the direction from the camera to the look point.
+func (c *FollowCameraObj) GetFront() glm.Vec3 {
+	front := c.look.Sub(&c.position)
+	if front.Len2() == 0 {
+		return defaultCameraFront
+	}
+	return front.Normalized()
+}
+
+func (c *FollowCameraObj) SetUp(up glm.Vec3) {
+	c.worldUp = up
+}
+
+// SetYaw sets the angle in degrees of the arm rotated around the pivot
+// from the offset, the positive value turns the view to the right.
+func (c *FollowCameraObj) SetYaw(yaw float32) {
+	c.armYaw = yaw
+}
+
+// SetPitch sets the angle in degrees of the arm rotated around the pivot
+// from the offset, the positive value turns the view up. It is limited to
+// ±89.9 degrees.
+func (c *FollowCameraObj) SetPitch(pitch float32) {
+	c.armPitch = glm.Clamp(pitch, -float32(maxElevation), float32(maxElevation))
+}
+
+func (c *FollowCameraObj) SetSensitivity(s float32) {
+	c.sensitivity = s
+}
+
+func (c *FollowCameraObj) SetSpeed(s float32) {
+	c.speed = s
+}
+
+// ProcessMovement is ignored since the camera follows the target, move the
+// target and call SetTarget instead.
+func (c *FollowCameraObj) ProcessMovement(dt float32, dir int, speedUp float32) {
+}
+
+// ProcessMouseMove rotates the arm around the pivot.
+func (c *FollowCameraObj) ProcessMouseMove(xOffset, yOffset float32, pitch bool) {
+	c.armYaw += xOffset * c.sensitivity
+	c.SetPitch(c.armPitch + yOffset*c.sensitivity)
+}
+
+// ProcessScroll scales the length of the arm by dollyFactor for each step.
+func (c *FollowCameraObj) ProcessScroll(yOffset float32) {
+	c.SetArmScale(c.armScale * float32(math.Pow(dollyFactor, float64(yOffset))))
+}
+
+// SetArmScale sets the scale of the arm length, it is limited between 0.1
+// and 10.
+func (c *FollowCameraObj) SetArmScale(s float32) {
+	if s < float32(minArmScale) || s != s {
+		s = float32(minArmScale)
+	}
+	if s > float32(maxArmScale) {
+		s = float32(maxArmScale)
+	}
+	c.armScale = s
+}
+
+func (c *FollowCameraObj) GetArmScale() float32 {
+	return c.armScale
+}
+
+func (c *FollowCameraObj) GetID() uint32 {
+	return c.id
+}
+
+func (c *FollowCameraObj) GetName() string {
+	return c.name
+}
+
+func (c *FollowCameraObj) SetName(name string) {
+	c.name = name
+}
+
+// pivot gets the start point of the arm in the world coordinates.
+func (c *FollowCameraObj) pivot() glm.Vec3 {
+	p := c.targetOrientation.Rotate(&c.lookOffset)
+	return c.targetPosition.Add(&p)
+}
+
+// desired gets the end point of the arm in the world coordinates.
+func (c *FollowCameraObj) desired() glm.Vec3 {
+	arm := c.offset.Sub(&c.lookOffset)
+	arm = arm.Mul(c.armScale)
+	q := localRotation(c.armYaw, c.armPitch, 0)
+	arm = q.Rotate(&arm)
+	arm = c.targetOrientation.Rotate(&arm)
+	pivot := c.pivot()
+	return pivot.Add(&arm)
+}
+
+// probe shortens the arm from the pivot to the position if it is blocked.
+func (c *FollowCameraObj) probe(pivot, pos glm.Vec3) glm.Vec3 {
+	if c.obstruction == nil {
+		return pos
+	}
+	arm := pos.Sub(&pivot)
+	length := arm.Len()
+	if length == 0 {
+		return pos
+	}
+	d, hit := c.obstruction(pivot, pos)
+	if !hit || d >= length {
+		return pos
+	}
+	d = max32(d-c.probeMargin, float32(minArmLength))
+	if d >= length {
+		return pos
+	}
+	pivot.AddScaledVec(d/length, &arm)
+	return pivot
+}
+
+func NewFollowCameraObj() (*FollowCameraObj, error) {
+	c := &FollowCameraObj{}
+	c.Init()
+	return c, nil
+}
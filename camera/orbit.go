@@ -0,0 +1,413 @@
+package camera
+
+import (
+	"math"
+
+	"github.com/engoengine/glm"
+)
+
+// OrbitCameraObj is the camera rotating around a target point, the
+// position of the camera is defined by the distance to the target, the
+// azimuth and the elevation in degrees.
+//
+// The azimuth is the angle around the world up axis, starting from +X
+// towards +Z, the elevation is the angle above the horizontal plane.
+type OrbitCameraObj struct {
+	id   uint32
+	name string
+
+	target  glm.Vec3
+	worldUp glm.Vec3
+
+	distance    float32
+	minDistance float32
+	maxDistance float32
+	azimuth     float32
+	elevation   float32
+
+	speed       float32
+	sensitivity float32
+	zoom        float32
+
+	projection
+}
+
+var (
+	defaultOrbitTarget      = glm.Vec3{0.0, 0.0, 0.0}
+	defaultOrbitDistance    = 5.0
+	defaultOrbitMinDistance = 0.01
+	defaultOrbitSensitivity = 0.2
+
+	// maxElevation keeps the camera away from the poles where the view
+	// matrix is undefined.
+	maxElevation = 89.9
+	// dollyFactor is the distance scale of one scroll step.
+	dollyFactor = 0.9
+)
+
+func (c *OrbitCameraObj) Init() error {
+	c.target = defaultOrbitTarget
+	c.worldUp = defaultCameraUp
+	c.distance = float32(defaultOrbitDistance)
+	c.minDistance = float32(defaultOrbitMinDistance)
+	c.maxDistance = 0
+	c.azimuth = 0
+	c.elevation = 0
+	c.speed = float32(defaultCameraSpeed)
+	c.sensitivity = float32(defaultOrbitSensitivity)
+	c.zoom = float32(defaultCameraZoom)
+	c.initProjection()
+
+	return nil
+}
+
+func (c *OrbitCameraObj) GetViewMatrix() glm.Mat4 {
+	pos := c.GetPosition()
+	return glm.LookAtV(&pos, &c.target, &c.worldUp)
+}
+
+// GetProjectionMatrix gets the projection matrix, the zoom value is the
+// vertical field of view in degrees of the perspective projection.
+func (c *OrbitCameraObj) GetProjectionMatrix() glm.Mat4 {
+	return c.matrix(c.zoom)
+}
+
+// GetViewProjectionMatrix gets the projection matrix multiplied by the
+// view matrix.
+func (c *OrbitCameraObj) GetViewProjectionMatrix() glm.Mat4 {
+	p := c.GetProjectionMatrix()
+	v := c.GetViewMatrix()
+	return p.Mul4(&v)
+}
+
+// GetPosition gets the camera position calculated from the target,
+// distance, azimuth and elevation.
+func (c *OrbitCameraObj) GetPosition() glm.Vec3 {
+	pos := c.target
+	offset := c.offset()
+	pos.AddScaledVec(c.distance, &offset)
+	return pos
+}
+
+// SetPosition moves the camera to the position and keeps looking at the
+// target, the distance, azimuth and elevation are recalculated.
+func (c *OrbitCameraObj) SetPosition(pos glm.Vec3) {
+	d := pos.Sub(&c.target)
+	dist := d.Len()
+	if dist == 0 {
+		return
+	}
+	q := upRotation(c.worldUp)
+	q.Conjugate()
+	d = q.Rotate(&d)
+	c.distance = dist
+	c.elevation = glm.RadToDeg(float32(math.Asin(float64(d.Y() / dist))))
+	c.azimuth = glm.RadToDeg(float32(math.Atan2(float64(d.Z()), float64(d.X()))))
+	c.clampElevation()
+}
+
+// GetOrientation gets the rotation from the camera space (looking at -Z
+// with +Y up) to the world space.
+func (c *OrbitCameraObj) GetOrientation() glm.Quat {
+	return lookRotation(c.GetFront(), c.worldUp)
+}
+
+// SetOrientation rotates the camera around its position, the target is
+// moved to the front of the camera at the same distance. The roll of the
+// orientation is ignored.
+func (c *OrbitCameraObj) SetOrientation(q glm.Quat) {
+	pos := c.GetPosition()
+	front := q.Rotate(&glm.Vec3{0, 0, -1})
+	if front.Len2() == 0 {
+		return
+	}
+	front.Normalize()
+	c.target = pos
+	c.target.AddScaledVec(c.distance, &front)
+	c.SetPosition(pos)
+}
+
+// GetTarget gets the target point the camera rotating around.
+func (c *OrbitCameraObj) GetTarget() glm.Vec3 {
+	return c.target
+}
+
+// SetTarget sets the target point, the distance, azimuth and elevation
+// are not changed so the camera moves with the target.
+func (c *OrbitCameraObj) SetTarget(t glm.Vec3) {
+	c.target = t
+}
+
+func (c *OrbitCameraObj) GetDistance() float32 {
+	return c.distance
+}
+
+// SetDistance sets the distance to the target, it is limited by the
+// distance limits.
+func (c *OrbitCameraObj) SetDistance(d float32) {
+	c.distance = c.clampDistance(d)
+}
+
+// SetDistanceLimits sets the minimum and maximum distance to the target,
+// the maximum distance less than or equal to 0 means no limit.
+func (c *OrbitCameraObj) SetDistanceLimits(min, max float32) {
+	if min > 0 {
+		c.minDistance = min
+	}
+	c.maxDistance = max
+	c.distance = c.clampDistance(c.distance)
+}
+
+func (c *OrbitCameraObj) GetAzimuth() float32 {
+	return c.azimuth
+}
+
+// SetAzimuth sets the angle in degrees around the world up axis.
+func (c *OrbitCameraObj) SetAzimuth(a float32) {
+	c.azimuth = a
+}
+
+func (c *OrbitCameraObj) GetElevation() float32 {
+	return c.elevation
+}
+
+// SetElevation sets the angle in degrees above the horizontal plane, it is
+// limited to (-90, 90).
+func (c *OrbitCameraObj) SetElevation(e float32) {
+	c.elevation = e
+	c.clampElevation()
+}
+
+func (c *OrbitCameraObj) GetZoom() float32 {
+	return c.zoom
+}
+
+// SetZoom sets the field of view, it is limited between MinZoom and MaxZoom.
+func (c *OrbitCameraObj) SetZoom(z float32) {
+	c.zoom = clampZoom(z)
+}
+
+// GetFront gets the direction from the camera to the target.
+func (c *OrbitCameraObj) GetFront() glm.Vec3 {
+	offset := c.offset()
+	return offset.Inverse()
+}
+
+// GetRight gets the right vector of the view plane.
+func (c *OrbitCameraObj) GetRight() glm.Vec3 {
+	front := c.GetFront()
+	right := front.Cross(&c.worldUp)
+	right.Normalize()
+	return right
+}
+
+// GetUp gets the up vector of the view plane.
+func (c *OrbitCameraObj) GetUp() glm.Vec3 {
+	right := c.GetRight()
+	front := c.GetFront()
+	return right.Cross(&front)
+}
+
+// SetUp sets the world up vector, the azimuth is measured around it.
+func (c *OrbitCameraObj) SetUp(up glm.Vec3) {
+	c.worldUp = up
+}
+
+// SetYaw sets the azimuth of the camera.
+func (c *OrbitCameraObj) SetYaw(yaw float32) {
+	c.SetAzimuth(yaw)
+}
+
+// SetPitch sets the elevation of the camera.
+func (c *OrbitCameraObj) SetPitch(pitch float32) {
+	c.SetElevation(pitch)
+}
+
+func (c *OrbitCameraObj) SetSensitivity(s float32) {
+	c.sensitivity = s
+}
+
+func (c *OrbitCameraObj) SetSpeed(s float32) {
+	c.speed = s
+}
+
+// Pan moves the target and the camera in the view plane, the offsets are
+// in world units along the right and up vectors of the view plane.
+func (c *OrbitCameraObj) Pan(dx, dy float32) {
+	right := c.GetRight()
+	up := c.GetUp()
+	c.target.AddScaledVec(dx, &right)
+	c.target.AddScaledVec(dy, &up)
+}
+
+// Dolly moves the camera towards the target by scaling the distance by
+// dollyFactor for each step, the negative steps move the camera away.
+func (c *OrbitCameraObj) Dolly(steps float32) {
+	scale := float32(math.Pow(dollyFactor, float64(steps)))
+	if c.mode == ProjectionOrthographic {
+		c.SetOrthoSize(c.orthoSize * scale)
+		return
+	}
+	c.SetDistance(c.distance * scale)
+}
+
+// ZoomToCursor dollies the camera by steps like Dolly and moves the target
+// so the point under the cursor stays under the cursor.
+//
+// The cursor position x and y are in the normalized device coordinates,
+// from -1 (left, bottom) to 1 (right, top).
+func (c *OrbitCameraObj) ZoomToCursor(x, y, steps float32) {
+	// the half size of the view plane at the target
+	var halfH float32
+	if c.mode == ProjectionOrthographic {
+		halfH = c.orthoSize / 2
+	} else {
+		halfH = c.distance * float32(math.Tan(float64(glm.DegToRad(c.zoom)/2)))
+	}
+	halfW := halfH * c.aspect
+
+	before := c.distance
+	beforeSize := c.orthoSize
+	c.Dolly(steps)
+	var t float32
+	if c.mode == ProjectionOrthographic {
+		t = 1 - c.orthoSize/beforeSize
+	} else {
+		t = 1 - c.distance/before
+	}
+	c.Pan(x*halfW*t, y*halfH*t)
+}
+
+// FrameBox moves the camera to fit the axis-aligned bounding box in the
+// view, the azimuth and elevation are not changed. The target is set to
+// the center of the box and the distance limits are ignored.
+func (c *OrbitCameraObj) FrameBox(min, max glm.Vec3) {
+	center := min.Add(&max)
+	c.target = center.Mul(0.5)
+	size := max.Sub(&min)
+	radius := size.Len() / 2
+	if radius == 0 {
+		return
+	}
+	if c.mode == ProjectionOrthographic {
+		h := 2 * radius
+		if c.aspect < 1 {
+			h /= c.aspect
+		}
+		c.SetOrthoSize(h)
+		c.distance = 2 * radius
+		return
+	}
+
+	// fit the bounding sphere in the smaller of the vertical and
+	// horizontal field of view
+	halfV := float64(glm.DegToRad(c.zoom)) / 2
+	halfH := math.Atan(math.Tan(halfV) * float64(c.aspect))
+	half := math.Min(halfV, halfH)
+	c.distance = radius / float32(math.Sin(half))
+}
+
+// ProcessMovement pans the target by the Left, Right, Up and Down
+// directions and dollies the camera by the Forward and Backward
+// directions, the speed is in world units per second.
+func (c *OrbitCameraObj) ProcessMovement(dt float32, dir int, speedUp float32) {
+	velocity := c.speed * dt * speedUp
+	switch dir {
+	case DirectionForward:
+		c.SetDistance(c.distance - velocity)
+	case DirectionBackwoard:
+		c.SetDistance(c.distance + velocity)
+	case DirectionLeft:
+		c.Pan(-velocity, 0)
+	case DirectionRight:
+		c.Pan(velocity, 0)
+	case DirectionUp:
+		c.Pan(0, velocity)
+	case DirectionDown:
+		c.Pan(0, -velocity)
+	}
+}
+
+// ProcessMouseMove rotates the camera around the target, the elevation is
+// always limited to (-90, 90) so the pitch param is ignored.
+func (c *OrbitCameraObj) ProcessMouseMove(xOffset, yOffset float32, pitch bool) {
+	c.azimuth += xOffset * c.sensitivity
+	c.elevation -= yOffset * c.sensitivity
+	c.clampElevation()
+}
+
+// ProcessScroll dollies the camera by the scroll offset.
+func (c *OrbitCameraObj) ProcessScroll(yOffset float32) {
+	c.Dolly(yOffset)
+}
+
+func (c *OrbitCameraObj) GetID() uint32 {
+	return c.id
+}
+
+func (c *OrbitCameraObj) GetName() string {
+	return c.name
+}
+
+func (c *OrbitCameraObj) SetName(name string) {
+	c.name = name
+}
+
+// offset gets the unit vector from the target to the camera.
+func (c *OrbitCameraObj) offset() glm.Vec3 {
+	az := float64(glm.DegToRad(c.azimuth))
+	el := float64(glm.DegToRad(c.elevation))
+	v := glm.Vec3{
+		float32(math.Cos(el) * math.Cos(az)),
+		float32(math.Sin(el)),
+		float32(math.Cos(el) * math.Sin(az)),
+	}
+	q := upRotation(c.worldUp)
+	return q.Rotate(&v)
+}
+
+func (c *OrbitCameraObj) clampElevation() {
+	if c.elevation > float32(maxElevation) {
+		c.elevation = float32(maxElevation)
+	}
+	if c.elevation < -float32(maxElevation) {
+		c.elevation = -float32(maxElevation)
+	}
+}
+
+func (c *OrbitCameraObj) clampDistance(d float32) float32 {
+	if d < c.minDistance {
+		d = c.minDistance
+	}
+	if c.maxDistance > 0 && d > c.maxDistance {
+		d = c.maxDistance
+	}
+	return d
+}
+
+// upRotation gets the rotation from the +Y up space to the space of the
+// world up vector.
+func upRotation(up glm.Vec3) glm.Quat {
+	y := glm.Vec3{0, 1, 0}
+	if up.Len2() == 0 {
+		return glm.QuatIdent()
+	}
+	up = glm.NormalizeVec3(up)
+	axis := y.Cross(&up)
+	if axis.Len2() < 1e-12 {
+		if up.Y() > 0 {
+			return glm.QuatIdent()
+		}
+		// up is -Y, flip around the X axis
+		return glm.QuatRotate(math.Pi, &glm.Vec3{1, 0, 0})
+	}
+	axis.Normalize()
+	angle := float32(math.Acos(float64(glm.Clamp(y.Dot(&up), -1, 1))))
+	return glm.QuatRotate(angle, &axis)
+}
+
+func NewOrbitCameraObj() (*OrbitCameraObj, error) {
+	c := &OrbitCameraObj{}
+	c.Init()
+	return c, nil
+}
@@ -0,0 +1,148 @@
+package camera
+
+import (
+	"fmt"
+
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/engoengine/glm"
+)
+
+// ProjectionMode is the projection mode of the camera.
+type ProjectionMode int
+
+const (
+	// ProjectionPerspective uses the zoom value of the camera as the
+	// vertical field of view in degrees.
+	ProjectionPerspective ProjectionMode = iota
+	// ProjectionOrthographic uses the orthographic size as the height of
+	// the view volume in world units.
+	ProjectionOrthographic
+)
+
+const (
+	// MinZoom and MaxZoom are the limits of the zoom value (vertical field
+	// of view in degrees) of the perspective projection.
+	MinZoom = 1.0
+	MaxZoom = 120.0
+
+	// DefaultNear is the default distance of the near plane.
+	DefaultNear = 0.1
+	// DefaultFar is the default distance of the far plane, same as the
+	// default view distance of the renderer, it is replaced by the view
+	// distance of the renderer when the camera is set to the window.
+	DefaultFar = 128.0
+
+	defaultAspect    = 1.0
+	defaultOrthoSize = 10.0
+)
+
+// projection has the projection parameters shared by the cameras.
+type projection struct {
+	mode      ProjectionMode
+	near      float32
+	far       float32
+	aspect    float32
+	orthoSize float32
+}
+
+func (p *projection) initProjection() {
+	p.mode = ProjectionPerspective
+	p.near = DefaultNear
+	p.far = DefaultFar
+	p.aspect = defaultAspect
+	p.orthoSize = defaultOrthoSize
+}
+
+// matrix calculates the projection matrix of the field of view in degrees.
+func (p *projection) matrix(fov float32) glm.Mat4 {
+	if p.mode == ProjectionOrthographic {
+		h := p.orthoSize / 2
+		w := h * p.aspect
+		return glm.Ortho(-w, w, -h, h, p.near, p.far)
+	}
+	return glm.Perspective(glm.DegToRad(clampZoom(fov)), p.aspect, p.near, p.far)
+}
+
+// SetProjectionMode sets the projection mode of the camera.
+func (p *projection) SetProjectionMode(m ProjectionMode) {
+	p.mode = m
+}
+
+func (p *projection) GetProjectionMode() ProjectionMode {
+	return p.mode
+}
+
+// SetNear sets the distance of the near plane, it should be positive and
+// less than the far plane, the invalid value is ignored.
+func (p *projection) SetNear(near float32) {
+	if near <= 0 || near >= p.far {
+		return
+	}
+	p.near = near
+}
+
+func (p *projection) GetNear() float32 {
+	return p.near
+}
+
+// SetFar sets the distance of the far plane, it should be greater than the
+// near plane, the invalid value is ignored.
+func (p *projection) SetFar(far float32) {
+	if far <= p.near {
+		return
+	}
+	p.far = far
+}
+
+func (p *projection) GetFar() float32 {
+	return p.far
+}
+
+// SetClipPlanes sets the distances of the near and far planes together,
+// SetNear and SetFar ignore the value crossing the other plane, so use this
+// method to move both planes past each other.
+func (p *projection) SetClipPlanes(near, far float32) error {
+	if near <= 0 || far <= near {
+		return fmt.Errorf("SetClipPlanes: invalid near %v and far %v: %w",
+			near, far, utils.ErrInvalidParameter)
+	}
+	p.near, p.far = near, far
+	return nil
+}
+
+// SetAspect sets the aspect ratio (width / height) of the projection,
+// it is updated by the window when the framebuffer is resized.
+func (p *projection) SetAspect(aspect float32) {
+	if aspect <= 0 {
+		return
+	}
+	p.aspect = aspect
+}
+
+func (p *projection) GetAspect() float32 {
+	return p.aspect
+}
+
+// SetOrthoSize sets the height of the orthographic view volume in world
+// units, the width is the height multiplied by the aspect ratio.
+func (p *projection) SetOrthoSize(size float32) {
+	if size <= 0 {
+		return
+	}
+	p.orthoSize = size
+}
+
+func (p *projection) GetOrthoSize() float32 {
+	return p.orthoSize
+}
+
+// clampZoom limits the zoom value between MinZoom and MaxZoom.
+func clampZoom(z float32) float32 {
+	if z < MinZoom {
+		return MinZoom
+	}
+	if z > MaxZoom {
+		return MaxZoom
+	}
+	return z
+}
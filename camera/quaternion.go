@@ -0,0 +1,367 @@
+package camera
+
+import (
+	"math"
+
+	"github.com/engoengine/glm"
+)
+
+// QuatCameraObj is the six degrees of freedom camera using a quaternion
+// as its orientation, it can roll and look in any direction without the
+// pitch limit of the Euler angle camera.
+//
+// The orientation rotates the local space of the camera to the world
+// space, the camera looks at the local -Z axis with the local +Y axis up.
+type QuatCameraObj struct {
+	id   uint32
+	name string
+
+	position    glm.Vec3
+	orientation glm.Quat
+	worldUp     glm.Vec3
+
+	speed       float32
+	rollSpeed   float32
+	sensitivity float32
+	zoom        float32
+
+	projection
+}
+
+var (
+	// defaultRollSpeed is the roll speed of ProcessMovement in degrees
+	// per second.
+	defaultRollSpeed = 90.0
+)
+
+func (c *QuatCameraObj) Init() error {
+	c.position = defaultCameraPosition
+	c.worldUp = defaultCameraUp
+	c.speed = float32(defaultCameraSpeed)
+	c.rollSpeed = float32(defaultRollSpeed)
+	c.sensitivity = float32(defaultCameraSensitivity)
+	c.zoom = float32(defaultCameraZoom)
+	c.initProjection()
+
+	// same direction as the Euler angle camera (yaw = 0, pitch = 0)
+	c.orientation = eulerOrientation(c.worldUp,
+		float32(defaultCameraYaw), float32(defaultCameraPitch), 0)
+
+	return nil
+}
+
+func (c *QuatCameraObj) GetViewMatrix() glm.Mat4 {
+	inv := c.orientation.Conjugated()
+	r := inv.Mat4()
+	t := glm.Translate3D(-c.position[0], -c.position[1], -c.position[2])
+	return r.Mul4(&t)
+}
+
+// GetProjectionMatrix gets the projection matrix, the zoom value is the
+// vertical field of view in degrees of the perspective projection.
+func (c *QuatCameraObj) GetProjectionMatrix() glm.Mat4 {
+	return c.matrix(c.zoom)
+}
+
+// GetViewProjectionMatrix gets the projection matrix multiplied by the
+// view matrix.
+func (c *QuatCameraObj) GetViewProjectionMatrix() glm.Mat4 {
+	p := c.GetProjectionMatrix()
+	v := c.GetViewMatrix()
+	return p.Mul4(&v)
+}
+
+func (c *QuatCameraObj) GetPosition() glm.Vec3 {
+	return c.position
+}
+
+func (c *QuatCameraObj) SetPosition(pos glm.Vec3) {
+	c.position = pos
+}
+
+// GetOrientation gets the rotation from the local space of the camera to
+// the world space.
+func (c *QuatCameraObj) GetOrientation() glm.Quat {
+	return c.orientation
+}
+
+// SetOrientation sets the rotation from the local space of the camera to
+// the world space, the quaternion is normalized.
+func (c *QuatCameraObj) SetOrientation(q glm.Quat) {
+	if q.Len() == 0 {
+		return
+	}
+	c.orientation = q.Normalized()
+}
+
+// SlerpTo rotates the camera towards the orientation by the spherical
+// linear interpolation, amount 0 keeps the current orientation and 1
+// reaches the target orientation.
+func (c *QuatCameraObj) SlerpTo(q glm.Quat, amount float32) {
+	c.orientation = Slerp(c.orientation, q, amount)
+}
+
+// LookAt rotates the camera to look at the target point, the roll is
+// reset so the camera up vector is aligned to the world up vector.
+func (c *QuatCameraObj) LookAt(target glm.Vec3) {
+	front := target.Sub(&c.position)
+	if front.Len2() == 0 {
+		return
+	}
+	c.orientation = lookRotation(front, c.worldUp)
+}
+
+func (c *QuatCameraObj) GetZoom() float32 {
+	return c.zoom
+}
+
+// SetZoom sets the zoom value, it is limited between MinZoom and MaxZoom.
+func (c *QuatCameraObj) SetZoom(z float32) {
+	c.zoom = clampZoom(z)
+}
+
+func (c *QuatCameraObj) GetFront() glm.Vec3 {
+	return c.orientation.Rotate(&glm.Vec3{0, 0, -1})
+}
+
+// GetRight gets the right vector of the camera.
+func (c *QuatCameraObj) GetRight() glm.Vec3 {
+	return c.orientation.Rotate(&glm.Vec3{1, 0, 0})
+}
+
+// GetUp gets the up vector of the camera, it is different from the world
+// up vector when the camera pitches or rolls.
+func (c *QuatCameraObj) GetUp() glm.Vec3 {
+	return c.orientation.Rotate(&glm.Vec3{0, 1, 0})
+}
+
+// SetUp sets the world up vector, the yaw, pitch and roll are measured
+// from it. The orientation is not changed.
+func (c *QuatCameraObj) SetUp(up glm.Vec3) {
+	if up.Len2() == 0 {
+		return
+	}
+	c.worldUp = up
+}
+
+// GetYaw gets the yaw angle in degrees around the world up vector.
+func (c *QuatCameraObj) GetYaw() float32 {
+	yaw, _, _ := c.euler()
+	return yaw
+}
+
+// SetYaw sets the yaw angle, the pitch and roll are kept.
+func (c *QuatCameraObj) SetYaw(yaw float32) {
+	_, pitch, roll := c.euler()
+	c.orientation = eulerOrientation(c.worldUp, yaw, pitch, roll)
+}
+
+// GetPitch gets the pitch angle in degrees above the horizontal plane.
+func (c *QuatCameraObj) GetPitch() float32 {
+	_, pitch, _ := c.euler()
+	return pitch
+}
+
+// SetPitch sets the pitch angle, the yaw and roll are kept.
+func (c *QuatCameraObj) SetPitch(pitch float32) {
+	yaw, _, roll := c.euler()
+	c.orientation = eulerOrientation(c.worldUp, yaw, pitch, roll)
+}
+
+// GetRoll gets the roll angle in degrees around the front vector, the
+// positive value banks the camera to the right.
+func (c *QuatCameraObj) GetRoll() float32 {
+	_, _, roll := c.euler()
+	return roll
+}
+
+// SetRoll sets the roll angle, the yaw and pitch are kept.
+func (c *QuatCameraObj) SetRoll(roll float32) {
+	yaw, pitch, _ := c.euler()
+	c.orientation = eulerOrientation(c.worldUp, yaw, pitch, roll)
+}
+
+// Rotate rotates the camera around its local axes, the angles are in
+// degrees: yaw turns right around the local up vector, pitch turns up
+// around the local right vector and roll banks right around the front
+// vector.
+func (c *QuatCameraObj) Rotate(yaw, pitch, roll float32) {
+	q := localRotation(yaw, pitch, roll)
+	c.orientation = c.orientation.Mul(&q)
+	c.orientation.Normalize()
+}
+
+func (c *QuatCameraObj) SetSensitivity(s float32) {
+	c.sensitivity = s
+}
+
+func (c *QuatCameraObj) SetSpeed(s float32) {
+	c.speed = s
+}
+
+// SetRollSpeed sets the roll speed in degrees per second of the
+// DirectionRollLeft and DirectionRollRight movements.
+func (c *QuatCameraObj) SetRollSpeed(s float32) {
+	c.rollSpeed = s
+}
+
+// ProcessMovement moves the camera along its local axes, the
+// DirectionRollLeft and DirectionRollRight roll the camera.
+func (c *QuatCameraObj) ProcessMovement(dt float32, dir int, speedUp float32) {
+	velocity := c.speed * dt * speedUp
+	switch dir {
+	case DirectionForward:
+		front := c.GetFront()
+		c.position.AddScaledVec(velocity, &front)
+	case DirectionBackwoard:
+		front := c.GetFront()
+		c.position.AddScaledVec(-velocity, &front)
+	case DirectionLeft:
+		right := c.GetRight()
+		c.position.AddScaledVec(-velocity, &right)
+	case DirectionRight:
+		right := c.GetRight()
+		c.position.AddScaledVec(velocity, &right)
+	case DirectionUp:
+		up := c.GetUp()
+		c.position.AddScaledVec(velocity, &up)
+	case DirectionDown:
+		up := c.GetUp()
+		c.position.AddScaledVec(-velocity, &up)
+	case DirectionRollLeft:
+		c.Rotate(0, 0, -c.rollSpeed*dt*speedUp)
+	case DirectionRollRight:
+		c.Rotate(0, 0, c.rollSpeed*dt*speedUp)
+	}
+}
+
+// ProcessMouseMove rotates the camera by the mouse offsets.
+//
+// If pitch is true, the camera yaws around the world up vector and the
+// pitch is limited to ±89.9 degrees like the Euler angle camera, the roll
+// is kept. Otherwise the camera rotates freely around its local axes.
+func (c *QuatCameraObj) ProcessMouseMove(xOffset, yOffset float32, pitch bool) {
+	xOffset *= c.sensitivity
+	yOffset *= c.sensitivity
+	if !pitch {
+		c.Rotate(xOffset, yOffset, 0)
+		return
+	}
+
+	yaw, p, roll := c.euler()
+	p += yOffset
+	if p > float32(maxElevation) {
+		p = float32(maxElevation)
+	}
+	if p < -float32(maxElevation) {
+		p = -float32(maxElevation)
+	}
+	c.orientation = eulerOrientation(c.worldUp, yaw+xOffset, p, roll)
+}
+
+func (c *QuatCameraObj) ProcessScroll(yOffset float32) {
+	c.zoom = clampZoom(c.zoom - c.sensitivity*yOffset)
+}
+
+func (c *QuatCameraObj) GetID() uint32 {
+	return c.id
+}
+
+func (c *QuatCameraObj) GetName() string {
+	return c.name
+}
+
+func (c *QuatCameraObj) SetName(name string) {
+	c.name = name
+}
+
+// euler gets the yaw, pitch and roll in degrees of the orientation
+// relative to the world up vector.
+func (c *QuatCameraObj) euler() (yaw, pitch, roll float32) {
+	inv := upRotation(c.worldUp)
+	inv.Conjugate()
+	q := inv.Mul(&c.orientation)
+	front := q.Rotate(&glm.Vec3{0, 0, -1})
+	up := q.Rotate(&glm.Vec3{0, 1, 0})
+
+	pitch = glm.RadToDeg(float32(math.Asin(float64(glm.Clamp(front.Y(), -1, 1)))))
+	if math.Abs(float64(front.Y())) > 0.9999 {
+		// looking straight up or down, the yaw is taken from the up vector
+		yaw = glm.RadToDeg(float32(math.Atan2(
+			float64(-up.Z()*front.Y()), float64(-up.X()*front.Y()))))
+		return yaw, pitch, 0
+	}
+	yaw = glm.RadToDeg(float32(math.Atan2(float64(front.Z()), float64(front.X()))))
+
+	// the roll is the angle between the up vector and the up vector
+	// without roll around the front vector
+	level := eulerOrientation(glm.Vec3{0, 1, 0}, yaw, pitch, 0)
+	up0 := level.Rotate(&glm.Vec3{0, 1, 0})
+	cross := up0.Cross(&up)
+	roll = glm.RadToDeg(float32(math.Atan2(
+		float64(cross.Dot(&front)), float64(up0.Dot(&up)))))
+	return yaw, pitch, roll
+}
+
+// eulerOrientation gets the orientation of the yaw, pitch and roll in
+// degrees, the yaw 0 and pitch 0 look at +X of the up space, the positive
+// yaw turns towards +Z.
+func eulerOrientation(up glm.Vec3, yaw, pitch, roll float32) glm.Quat {
+	// the local -Z is rotated to +X by -90 degrees around +Y
+	q := glm.QuatRotate(glm.DegToRad(-yaw-90), &glm.Vec3{0, 1, 0})
+	local := localRotation(0, pitch, roll)
+	q = q.Mul(&local)
+	u := upRotation(up)
+	q = u.Mul(&q)
+	return q.Normalized()
+}
+
+// localRotation gets the rotation around the local axes in degrees, see
+// QuatCameraObj.Rotate.
+func localRotation(yaw, pitch, roll float32) glm.Quat {
+	y := glm.QuatRotate(glm.DegToRad(-yaw), &glm.Vec3{0, 1, 0})
+	p := glm.QuatRotate(glm.DegToRad(pitch), &glm.Vec3{1, 0, 0})
+	r := glm.QuatRotate(glm.DegToRad(-roll), &glm.Vec3{0, 0, 1})
+	q := y.Mul(&p)
+	return q.Mul(&r)
+}
+
+// lookRotation gets the orientation looking at the front direction with
+// the up vector.
+func lookRotation(front, up glm.Vec3) glm.Quat {
+	f := front.Normalized()
+	r := f.Cross(&up)
+	if r.Len2() < 1e-12 {
+		// front is parallel to up, use any perpendicular right vector
+		r = f.Cross(&glm.Vec3{1, 0, 0})
+		if r.Len2() < 1e-12 {
+			r = f.Cross(&glm.Vec3{0, 0, 1})
+		}
+	}
+	r.Normalize()
+	u := r.Cross(&f)
+	m := glm.Mat4{
+		r[0], r[1], r[2], 0,
+		u[0], u[1], u[2], 0,
+		-f[0], -f[1], -f[2], 0,
+		0, 0, 0, 1,
+	}
+	q := glm.Mat4ToQuat(&m)
+	return q.Normalized()
+}
+
+// Slerp interpolates the orientations by the spherical linear
+// interpolation along the shortest path.
+func Slerp(from, to glm.Quat, amount float32) glm.Quat {
+	if from.Dot(&to) < 0 {
+		to = to.Scale(-1)
+	}
+	q := glm.QuatSlerp(&from, &to, amount)
+	return q.Normalized()
+}
+
+func NewQuatCameraObj() (*QuatCameraObj, error) {
+	c := &QuatCameraObj{}
+	c.Init()
+	return c, nil
+}
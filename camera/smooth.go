@@ -0,0 +1,278 @@
+package camera
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/STARRY-S/aperture"
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/engoengine/glm"
+)
+
+// SmoothCameraObj adds the acceleration, deceleration and mouse-look
+// smoothing to the wrapped camera.
+//
+// The Process methods only record the input of the frame, the input is
+// applied to the wrapped camera by Update. The velocity and the pending
+// mouse offsets follow the exponential filters, so the motion is the same
+// at any frame rate.
+type SmoothCameraObj struct {
+	aperture.Camera
+
+	// acceleration and deceleration are the rates (1/s) of the velocity
+	// approaching the input velocity and zero, 0 means instant.
+	acceleration float32
+	deceleration float32
+	// lookSmoothing and zoomSmoothing are the rates (1/s) of applying the
+	// pending mouse and scroll offsets, 0 means instant.
+	lookSmoothing float32
+	zoomSmoothing float32
+
+	// input and velocity are in the speed-up value per second along the
+	// local axes, see smoothAxes.
+	input    [smoothAxisCount]float32
+	velocity [smoothAxisCount]float32
+
+	pendingX      float32
+	pendingY      float32
+	pendingScroll float32
+	pitch         bool
+}
+
+const (
+	smoothAxisForward = iota
+	smoothAxisRight
+	smoothAxisUp
+	smoothAxisRoll
+	smoothAxisCount
+)
+
+// smoothAxes are the positive and negative directions of the local axes.
+var smoothAxes = [smoothAxisCount][2]int{
+	smoothAxisForward: {DirectionForward, DirectionBackwoard},
+	smoothAxisRight:   {DirectionRight, DirectionLeft},
+	smoothAxisUp:      {DirectionUp, DirectionDown},
+	smoothAxisRoll:    {DirectionRollRight, DirectionRollLeft},
+}
+
+var (
+	defaultSmoothAcceleration  = 10.0
+	defaultSmoothDeceleration  = 8.0
+	defaultSmoothLookSmoothing = 25.0
+	defaultSmoothZoomSmoothing = 15.0
+
+	// smoothEpsilon is the threshold below which the motion stops.
+	smoothEpsilon = 1e-4
+)
+
+// Init initializes the wrapped camera and resets the velocity and the
+// pending input.
+func (c *SmoothCameraObj) Init() error {
+	c.Reset()
+	if c.Camera == nil {
+		return nil
+	}
+	return c.Camera.Init()
+}
+
+// Reset stops the camera immediately and drops the pending input.
+func (c *SmoothCameraObj) Reset() {
+	c.input = [smoothAxisCount]float32{}
+	c.velocity = [smoothAxisCount]float32{}
+	c.pendingX, c.pendingY, c.pendingScroll = 0, 0, 0
+}
+
+// GetCamera gets the wrapped camera.
+func (c *SmoothCameraObj) GetCamera() aperture.Camera {
+	return c.Camera
+}
+
+// SetAcceleration sets the rate (1/s) of the velocity approaching the
+// input velocity, 0 disables the acceleration.
+func (c *SmoothCameraObj) SetAcceleration(rate float32) {
+	c.acceleration = max32(rate, 0)
+}
+
+func (c *SmoothCameraObj) GetAcceleration() float32 {
+	return c.acceleration
+}
+
+// SetDeceleration sets the rate (1/s) of the velocity damping when there
+// is no input, 0 stops the camera immediately.
+func (c *SmoothCameraObj) SetDeceleration(rate float32) {
+	c.deceleration = max32(rate, 0)
+}
+
+func (c *SmoothCameraObj) GetDeceleration() float32 {
+	return c.deceleration
+}
+
+// SetLookSmoothing sets the rate (1/s) of applying the mouse movements,
+// the larger value is more responsive, 0 disables the smoothing.
+func (c *SmoothCameraObj) SetLookSmoothing(rate float32) {
+	c.lookSmoothing = max32(rate, 0)
+}
+
+func (c *SmoothCameraObj) GetLookSmoothing() float32 {
+	return c.lookSmoothing
+}
+
+// SetZoomSmoothing sets the rate (1/s) of applying the scroll offsets,
+// 0 disables the smoothing.
+func (c *SmoothCameraObj) SetZoomSmoothing(rate float32) {
+	c.zoomSmoothing = max32(rate, 0)
+}
+
+func (c *SmoothCameraObj) GetZoomSmoothing() float32 {
+	return c.zoomSmoothing
+}
+
+// GetVelocity gets the current velocity along the forward, right and up
+// axes of the camera, in the speed-up value (multiple of the camera
+// speed).
+func (c *SmoothCameraObj) GetVelocity() glm.Vec3 {
+	return glm.Vec3{
+		c.velocity[smoothAxisForward],
+		c.velocity[smoothAxisRight],
+		c.velocity[smoothAxisUp],
+	}
+}
+
+// ProcessMovement records the movement input of the frame, the dt is
+// ignored and the movement is applied by Update.
+func (c *SmoothCameraObj) ProcessMovement(dt float32, dir int, speedUp float32) {
+	for i, axis := range smoothAxes {
+		switch dir {
+		case axis[0]:
+			c.input[i] += speedUp
+		case axis[1]:
+			c.input[i] -= speedUp
+		}
+	}
+}
+
+// ProcessMouseMove records the mouse offsets, they are applied by Update.
+func (c *SmoothCameraObj) ProcessMouseMove(xOffset, yOffset float32, pitch bool) {
+	c.pendingX += xOffset
+	c.pendingY += yOffset
+	c.pitch = pitch
+}
+
+// ProcessScroll records the scroll offset, it is applied by Update.
+func (c *SmoothCameraObj) ProcessScroll(yOffset float32) {
+	c.pendingScroll += yOffset
+}
+
+// Update applies the recorded input of the frame to the wrapped camera,
+// it should be called once per frame with the delta time in seconds.
+func (c *SmoothCameraObj) Update(dt float32) {
+	if c.Camera == nil || dt <= 0 {
+		return
+	}
+
+	for i, axis := range smoothAxes {
+		target := c.input[i]
+		rate := c.acceleration
+		if target == 0 {
+			rate = c.deceleration
+		}
+		var distance float32
+		c.velocity[i], distance = Damp(c.velocity[i], target, rate, dt)
+		if target == 0 && abs32(c.velocity[i]) < float32(smoothEpsilon) {
+			c.velocity[i] = 0
+		}
+		// the camera moves speed * dt * speedUp in ProcessMovement
+		switch {
+		case distance > float32(smoothEpsilon)*dt:
+			c.Camera.ProcessMovement(1, axis[0], distance)
+		case distance < -float32(smoothEpsilon)*dt:
+			c.Camera.ProcessMovement(1, axis[1], -distance)
+		}
+	}
+	c.input = [smoothAxisCount]float32{}
+
+	if c.pendingX != 0 || c.pendingY != 0 {
+		a := Smoothing(c.lookSmoothing, dt)
+		x, y := c.pendingX*a, c.pendingY*a
+		c.pendingX -= x
+		c.pendingY -= y
+		if abs32(c.pendingX) < float32(smoothEpsilon) && abs32(c.pendingY) < float32(smoothEpsilon) {
+			x, y = x+c.pendingX, y+c.pendingY
+			c.pendingX, c.pendingY = 0, 0
+		}
+		c.Camera.ProcessMouseMove(x, y, c.pitch)
+	}
+
+	if c.pendingScroll != 0 {
+		s := c.pendingScroll * Smoothing(c.zoomSmoothing, dt)
+		c.pendingScroll -= s
+		if abs32(c.pendingScroll) < float32(smoothEpsilon) {
+			s += c.pendingScroll
+			c.pendingScroll = 0
+		}
+		c.Camera.ProcessScroll(s)
+	}
+}
+
+// Damp moves the value towards the target by the exponential filter of the
+// rate (1/s) in the delta time, it returns the new value and the integral
+// of the value over the delta time (the distance if the value is the
+// velocity). The rate less than or equal to 0 reaches the target
+// instantly.
+func Damp(value, target, rate, dt float32) (float32, float32) {
+	if rate <= 0 {
+		return target, target * dt
+	}
+	e := float32(math.Exp(float64(-rate * dt)))
+	integral := target*dt + (value-target)*(1-e)/rate
+	return target + (value-target)*e, integral
+}
+
+// DampVec3 moves the vector towards the target by the exponential filter
+// of the rate (1/s) in the delta time, see Damp.
+func DampVec3(value, target glm.Vec3, rate, dt float32) glm.Vec3 {
+	for i := range value {
+		value[i], _ = Damp(value[i], target[i], rate, dt)
+	}
+	return value
+}
+
+// Smoothing gets the fraction of the remaining value applied in the delta
+// time by the exponential filter of the rate (1/s), the rate less than or
+// equal to 0 applies all.
+func Smoothing(rate, dt float32) float32 {
+	if rate <= 0 {
+		return 1
+	}
+	return 1 - float32(math.Exp(float64(-rate*dt)))
+}
+
+func abs32(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// NewSmoothCameraObj wraps the camera with the default smoothing, the
+// wrapped camera should be initialized.
+func NewSmoothCameraObj(cam aperture.Camera) (*SmoothCameraObj, error) {
+	if cam == nil {
+		return nil, fmt.Errorf("NewSmoothCameraObj: %w", utils.ErrInvalidPointer)
+	}
+	c := &SmoothCameraObj{
+		Camera:        cam,
+		acceleration:  float32(defaultSmoothAcceleration),
+		deceleration:  float32(defaultSmoothDeceleration),
+		lookSmoothing: float32(defaultSmoothLookSmoothing),
+		zoomSmoothing: float32(defaultSmoothZoomSmoothing),
+	}
+	return c, nil
+}
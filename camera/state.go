@@ -0,0 +1,348 @@
+package camera
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/engoengine/glm"
+)
+
+// The camera state is saved to JSON without the runtime state such as the
+// velocity of the smoothing, the aspect ratio is saved but it is usually
+// overwritten by the window. Unmarshaling to the camera keeps the current
+// values of the missing fields, the zero value camera is initialized first.
+
+// projectionState is the JSON format of the projection.
+type projectionState struct {
+	Mode      ProjectionMode `json:"mode"`
+	Near      float32        `json:"near"`
+	Far       float32        `json:"far"`
+	Aspect    float32        `json:"aspect"`
+	OrthoSize float32        `json:"ortho_size"`
+}
+
+func (m ProjectionMode) MarshalText() ([]byte, error) {
+	switch m {
+	case ProjectionPerspective:
+		return []byte("perspective"), nil
+	case ProjectionOrthographic:
+		return []byte("orthographic"), nil
+	}
+	return nil, fmt.Errorf("MarshalText: unknown projection mode %d: %w",
+		int(m), utils.ErrInvalidParameter)
+}
+
+func (m *ProjectionMode) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "perspective":
+		*m = ProjectionPerspective
+	case "orthographic":
+		*m = ProjectionOrthographic
+	default:
+		return fmt.Errorf("UnmarshalText: unknown projection mode %q: %w",
+			text, utils.ErrInvalidParameter)
+	}
+	return nil
+}
+
+func (p *projection) state() projectionState {
+	return projectionState{
+		Mode:      p.mode,
+		Near:      p.near,
+		Far:       p.far,
+		Aspect:    p.aspect,
+		OrthoSize: p.orthoSize,
+	}
+}
+
+func (p *projection) setState(s projectionState) {
+	p.SetProjectionMode(s.Mode)
+	// the invalid saved planes keep the current planes
+	p.SetClipPlanes(s.Near, s.Far)
+	p.SetAspect(s.Aspect)
+	p.SetOrthoSize(s.OrthoSize)
+}
+
+// stateCamera is the camera saved to the state JSON.
+type stateCamera interface {
+	Init() error
+	json.Marshaler
+}
+
+// mergeState initializes the zero value camera, fills v with the current
+// state of the camera and overwrites it with the fields in data, so the
+// fields missing in data keep the current values.
+func mergeState(c stateCamera, p *projection, data []byte, v interface{}) error {
+	if p.far == 0 {
+		c.Init()
+	}
+	current, err := c.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(current, v); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// saveJSON saves the value to the indented JSON file.
+func saveJSON(file string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// loadJSON loads the JSON file to the value.
+func loadJSON(file string, v interface{}) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return utils.ErrEmptyFile
+	}
+	return json.Unmarshal(data, v)
+}
+
+// quatArray is the JSON format of the quaternion: [x, y, z, w].
+type quatArray [4]float32
+
+func newQuatArray(q glm.Quat) quatArray {
+	return quatArray{q.V[0], q.V[1], q.V[2], q.W}
+}
+
+func (a quatArray) quat() glm.Quat {
+	return glm.Quat{W: a[3], V: glm.Vec3{a[0], a[1], a[2]}}
+}
+
+// cameraState is the JSON format of CameraObj.
+type cameraState struct {
+	Name        string          `json:"name,omitempty"`
+	Position    glm.Vec3        `json:"position"`
+	Up          glm.Vec3        `json:"up"`
+	Yaw         float32         `json:"yaw"`
+	Pitch       float32         `json:"pitch"`
+	Zoom        float32         `json:"zoom"`
+	Speed       float32         `json:"speed"`
+	Sensitivity float32         `json:"sensitivity"`
+	Projection  projectionState `json:"projection"`
+}
+
+func (c *CameraObj) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cameraState{
+		Name:        c.name,
+		Position:    c.position,
+		Up:          c.up,
+		Yaw:         c.yaw,
+		Pitch:       c.pitch,
+		Zoom:        c.zoom,
+		Speed:       c.speed,
+		Sensitivity: c.sensitivity,
+		Projection:  c.state(),
+	})
+}
+
+func (c *CameraObj) UnmarshalJSON(data []byte) error {
+	s := cameraState{}
+	if err := mergeState(c, &c.projection, data, &s); err != nil {
+		return err
+	}
+	c.name = s.Name
+	c.position = s.Position
+	c.up = s.Up
+	c.yaw = s.Yaw
+	c.pitch = s.Pitch
+	c.SetZoom(s.Zoom)
+	c.speed = s.Speed
+	c.sensitivity = s.Sensitivity
+	c.setState(s.Projection)
+	c.updateVectors()
+	return nil
+}
+
+// orbitState is the JSON format of OrbitCameraObj.
+type orbitState struct {
+	Name        string          `json:"name,omitempty"`
+	Target      glm.Vec3        `json:"target"`
+	Up          glm.Vec3        `json:"up"`
+	Distance    float32         `json:"distance"`
+	MinDistance float32         `json:"min_distance"`
+	MaxDistance float32         `json:"max_distance"`
+	Azimuth     float32         `json:"azimuth"`
+	Elevation   float32         `json:"elevation"`
+	Zoom        float32         `json:"zoom"`
+	Speed       float32         `json:"speed"`
+	Sensitivity float32         `json:"sensitivity"`
+	Projection  projectionState `json:"projection"`
+}
+
+func (c *OrbitCameraObj) MarshalJSON() ([]byte, error) {
+	return json.Marshal(orbitState{
+		Name:        c.name,
+		Target:      c.target,
+		Up:          c.worldUp,
+		Distance:    c.distance,
+		MinDistance: c.minDistance,
+		MaxDistance: c.maxDistance,
+		Azimuth:     c.azimuth,
+		Elevation:   c.elevation,
+		Zoom:        c.zoom,
+		Speed:       c.speed,
+		Sensitivity: c.sensitivity,
+		Projection:  c.state(),
+	})
+}
+
+func (c *OrbitCameraObj) UnmarshalJSON(data []byte) error {
+	s := orbitState{}
+	if err := mergeState(c, &c.projection, data, &s); err != nil {
+		return err
+	}
+	c.name = s.Name
+	c.target = s.Target
+	c.worldUp = s.Up
+	c.SetDistanceLimits(s.MinDistance, s.MaxDistance)
+	c.SetDistance(s.Distance)
+	c.azimuth = s.Azimuth
+	c.SetElevation(s.Elevation)
+	c.SetZoom(s.Zoom)
+	c.speed = s.Speed
+	c.sensitivity = s.Sensitivity
+	c.setState(s.Projection)
+	return nil
+}
+
+// quatState is the JSON format of QuatCameraObj.
+type quatState struct {
+	Name        string          `json:"name,omitempty"`
+	Position    glm.Vec3        `json:"position"`
+	Orientation quatArray       `json:"orientation"`
+	Up          glm.Vec3        `json:"up"`
+	Zoom        float32         `json:"zoom"`
+	Speed       float32         `json:"speed"`
+	RollSpeed   float32         `json:"roll_speed"`
+	Sensitivity float32         `json:"sensitivity"`
+	Projection  projectionState `json:"projection"`
+}
+
+func (c *QuatCameraObj) MarshalJSON() ([]byte, error) {
+	return json.Marshal(quatState{
+		Name:        c.name,
+		Position:    c.position,
+		Orientation: newQuatArray(c.orientation),
+		Up:          c.worldUp,
+		Zoom:        c.zoom,
+		Speed:       c.speed,
+		RollSpeed:   c.rollSpeed,
+		Sensitivity: c.sensitivity,
+		Projection:  c.state(),
+	})
+}
+
+func (c *QuatCameraObj) UnmarshalJSON(data []byte) error {
+	s := quatState{}
+	if err := mergeState(c, &c.projection, data, &s); err != nil {
+		return err
+	}
+	c.name = s.Name
+	c.position = s.Position
+	c.SetOrientation(s.Orientation.quat())
+	c.SetUp(s.Up)
+	c.SetZoom(s.Zoom)
+	c.speed = s.Speed
+	c.rollSpeed = s.RollSpeed
+	c.sensitivity = s.Sensitivity
+	c.setState(s.Projection)
+	return nil
+}
+
+// followState is the JSON format of FollowCameraObj, the target and the
+// obstruction test are not saved.
+type followState struct {
+	Name         string          `json:"name,omitempty"`
+	Offset       glm.Vec3        `json:"offset"`
+	LookOffset   glm.Vec3        `json:"look_offset"`
+	Up           glm.Vec3        `json:"up"`
+	Yaw          float32         `json:"yaw"`
+	Pitch        float32         `json:"pitch"`
+	ArmScale     float32         `json:"arm_scale"`
+	Stiffness    float32         `json:"stiffness"`
+	DampingRatio float32         `json:"damping_ratio"`
+	LookAhead    float32         `json:"look_ahead"`
+	ProbeMargin  float32         `json:"probe_margin"`
+	Zoom         float32         `json:"zoom"`
+	Speed        float32         `json:"speed"`
+	Sensitivity  float32         `json:"sensitivity"`
+	Projection   projectionState `json:"projection"`
+}
+
+func (c *FollowCameraObj) MarshalJSON() ([]byte, error) {
+	return json.Marshal(followState{
+		Name:         c.name,
+		Offset:       c.offset,
+		LookOffset:   c.lookOffset,
+		Up:           c.worldUp,
+		Yaw:          c.armYaw,
+		Pitch:        c.armPitch,
+		ArmScale:     c.armScale,
+		Stiffness:    c.stiffness,
+		DampingRatio: c.dampingRatio,
+		LookAhead:    c.lookAhead,
+		ProbeMargin:  c.probeMargin,
+		Zoom:         c.zoom,
+		Speed:        c.speed,
+		Sensitivity:  c.sensitivity,
+		Projection:   c.state(),
+	})
+}
+
+// UnmarshalJSON loads the state and snaps the camera to the end of the
+// arm.
+func (c *FollowCameraObj) UnmarshalJSON(data []byte) error {
+	s := followState{}
+	if err := mergeState(c, &c.projection, data, &s); err != nil {
+		return err
+	}
+	c.name = s.Name
+	c.offset = s.Offset
+	c.lookOffset = s.LookOffset
+	c.worldUp = s.Up
+	c.SetYaw(s.Yaw)
+	c.SetPitch(s.Pitch)
+	c.SetArmScale(s.ArmScale)
+	c.SetSpring(s.Stiffness, s.DampingRatio)
+	c.lookAhead = s.LookAhead
+	c.SetProbeMargin(s.ProbeMargin)
+	c.SetZoom(s.Zoom)
+	c.speed = s.Speed
+	c.sensitivity = s.Sensitivity
+	c.setState(s.Projection)
+	c.Snap()
+	return nil
+}
+
+// MarshalJSON saves the state of the wrapped camera, the smoothing
+// settings are not saved.
+func (c *SmoothCameraObj) MarshalJSON() ([]byte, error) {
+	m, ok := c.Camera.(json.Marshaler)
+	if !ok {
+		return nil, fmt.Errorf("MarshalJSON: %w", utils.ErrInvalidDataType)
+	}
+	return m.MarshalJSON()
+}
+
+// UnmarshalJSON loads the state of the wrapped camera and stops the
+// camera.
+func (c *SmoothCameraObj) UnmarshalJSON(data []byte) error {
+	u, ok := c.Camera.(json.Unmarshaler)
+	if !ok {
+		return fmt.Errorf("UnmarshalJSON: %w", utils.ErrInvalidDataType)
+	}
+	c.Reset()
+	return u.UnmarshalJSON(data)
+}
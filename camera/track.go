@@ -0,0 +1,519 @@
+package camera
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/STARRY-S/aperture"
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/engoengine/glm"
+)
+
+// Interpolation is the interpolation of the keyframe positions.
+type Interpolation string
+
+const (
+	InterpolationLinear Interpolation = "linear"
+	// InterpolationCatmullRom passes the curve through all keyframes with
+	// the tangents calculated from the neighbouring keyframes.
+	InterpolationCatmullRom Interpolation = "catmull-rom"
+	// InterpolationBezier uses the handles of the keyframes as the control
+	// points of the cubic Bézier curves, the missing handles are
+	// calculated like InterpolationCatmullRom.
+	InterpolationBezier Interpolation = "bezier"
+)
+
+// Ease is the ease curve of the segment from a keyframe to the next one.
+type Ease string
+
+const (
+	EaseLinear Ease = "linear"
+	EaseIn     Ease = "ease-in"
+	EaseOut    Ease = "ease-out"
+	EaseInOut  Ease = "ease-in-out"
+	// EaseConstant holds the keyframe until the next one.
+	EaseConstant Ease = "constant"
+)
+
+var (
+	// closedPositionEpsilon and closedOrientationEpsilon are the tolerance
+	// of the same pose of the first and last keyframes of the closed track.
+	closedPositionEpsilon    = 1e-4
+	closedOrientationEpsilon = 1e-6
+)
+
+// Apply maps the linear progress t in [0, 1] of the segment by the ease
+// curve, the empty and unknown ease curves are linear.
+func (e Ease) Apply(t float32) float32 {
+	switch e {
+	case EaseIn:
+		return t * t
+	case EaseOut:
+		return 1 - (1-t)*(1-t)
+	case EaseInOut:
+		return t * t * (3 - 2*t)
+	case EaseConstant:
+		return 0
+	}
+	return t
+}
+
+// Keyframe is the camera pose at the time of the track.
+type Keyframe struct {
+	// Time is the time of the keyframe in seconds.
+	Time float32
+	// Position is the camera position in the world coordinates.
+	Position glm.Vec3
+	// Orientation is the rotation from the camera space (looking at -Z
+	// with +Y up) to the world space, see QuatCameraObj.
+	Orientation glm.Quat
+	// Zoom is the field of view in degrees, the default zoom is used if
+	// it is less than or equal to 0.
+	Zoom float32
+	// Ease is the ease curve of the segment from this keyframe to the
+	// next one, default is EaseLinear.
+	Ease Ease
+	// InHandle and OutHandle are the offsets of the Bézier control points
+	// before and after the keyframe relative to the position, they are
+	// only used by InterpolationBezier.
+	InHandle  *glm.Vec3
+	OutHandle *glm.Vec3
+}
+
+// keyframeJSON is the JSON format of the keyframe, the orientation is
+// saved as [x, y, z, w].
+type keyframeJSON struct {
+	Time        float32   `json:"time"`
+	Position    glm.Vec3  `json:"position"`
+	Orientation quatArray `json:"orientation"`
+	Zoom        float32   `json:"zoom,omitempty"`
+	Ease        Ease      `json:"ease,omitempty"`
+	InHandle    *glm.Vec3 `json:"in,omitempty"`
+	OutHandle   *glm.Vec3 `json:"out,omitempty"`
+}
+
+func (k Keyframe) MarshalJSON() ([]byte, error) {
+	return json.Marshal(keyframeJSON{
+		Time:        k.Time,
+		Position:    k.Position,
+		Orientation: newQuatArray(k.Orientation),
+		Zoom:        k.Zoom,
+		Ease:        k.Ease,
+		InHandle:    k.InHandle,
+		OutHandle:   k.OutHandle,
+	})
+}
+
+func (k *Keyframe) UnmarshalJSON(data []byte) error {
+	j := keyframeJSON{}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*k = Keyframe{
+		Time:        j.Time,
+		Position:    j.Position,
+		Orientation: j.Orientation.quat(),
+		Zoom:        j.Zoom,
+		Ease:        j.Ease,
+		InHandle:    j.InHandle,
+		OutHandle:   j.OutHandle,
+	}
+	return nil
+}
+
+// TrackObj is the camera path animation, the keyframe positions are
+// interpolated by the spline and the orientations are interpolated by the
+// spherical linear interpolation.
+type TrackObj struct {
+	keyframes     []Keyframe
+	interpolation Interpolation
+	loop          bool
+}
+
+// TrackInitParam is used for customize the parameters when init track.
+type TrackInitParam struct {
+	// Interpolation is the interpolation of the positions, default is
+	// InterpolationCatmullRom.
+	Interpolation Interpolation
+	// Loop repeats the track after the last keyframe.
+	Loop bool
+}
+
+// trackJSON is the JSON format of the track.
+type trackJSON struct {
+	Interpolation Interpolation `json:"interpolation"`
+	Loop          bool          `json:"loop"`
+	Keyframes     []Keyframe    `json:"keyframes"`
+}
+
+func (t *TrackObj) Init(initParam interface{}) error {
+	if t == nil {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidPointer)
+	}
+
+	if initParam == nil {
+		initParam = TrackInitParam{}
+	}
+	p, ok := initParam.(TrackInitParam)
+	if !ok {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidDataType)
+	}
+	if p.Interpolation == "" {
+		p.Interpolation = InterpolationCatmullRom
+	}
+	if !p.Interpolation.valid() {
+		return fmt.Errorf("Init: unknown interpolation %q: %w",
+			p.Interpolation, utils.ErrInvalidParameter)
+	}
+	t.keyframes = nil
+	t.interpolation = p.Interpolation
+	t.loop = p.Loop
+	return nil
+}
+
+// AddKeyframe adds the keyframe to the track, the keyframes are sorted by
+// time and the keyframe at the same time as an existing one replaces it.
+func (t *TrackObj) AddKeyframe(k Keyframe) error {
+	if k.Orientation.Len() == 0 {
+		return fmt.Errorf("AddKeyframe: invalid orientation: %w", utils.ErrInvalidParameter)
+	}
+	k.Orientation = k.Orientation.Normalized()
+	i := sort.Search(len(t.keyframes), func(i int) bool {
+		return t.keyframes[i].Time >= k.Time
+	})
+	if i < len(t.keyframes) && t.keyframes[i].Time == k.Time {
+		t.keyframes[i] = k
+		return nil
+	}
+	t.keyframes = append(t.keyframes, Keyframe{})
+	copy(t.keyframes[i+1:], t.keyframes[i:])
+	t.keyframes[i] = k
+	return nil
+}
+
+// AddCameraKeyframe adds the current pose of the camera as the keyframe
+// at the time, the roll of the camera is kept only if it provides the
+// orientation like QuatCameraObj.
+func (t *TrackObj) AddCameraKeyframe(time float32, cam aperture.Camera) error {
+	if cam == nil {
+		return fmt.Errorf("AddCameraKeyframe: %w", utils.ErrInvalidPointer)
+	}
+	if err := t.AddKeyframe(Keyframe{
+		Time:        time,
+		Position:    cam.GetPosition(),
+		Orientation: getOrientation(cam),
+		Zoom:        cam.GetZoom(),
+	}); err != nil {
+		return fmt.Errorf("AddCameraKeyframe: %w", err)
+	}
+	return nil
+}
+
+// RemoveKeyframe removes the ith keyframe.
+func (t *TrackObj) RemoveKeyframe(i int) error {
+	if i < 0 || i >= len(t.keyframes) {
+		return fmt.Errorf("RemoveKeyframe: %w", utils.ErrPositionExceed)
+	}
+	t.keyframes = append(t.keyframes[:i], t.keyframes[i+1:]...)
+	return nil
+}
+
+// GetKeyframes gets the keyframes sorted by time.
+func (t *TrackObj) GetKeyframes() []Keyframe {
+	return t.keyframes
+}
+
+func (t *TrackObj) GetKeyframeCount() int {
+	return len(t.keyframes)
+}
+
+// Clear removes all keyframes.
+func (t *TrackObj) Clear() {
+	t.keyframes = nil
+}
+
+// GetDuration gets the time from the first keyframe to the last one.
+func (t *TrackObj) GetDuration() float32 {
+	if len(t.keyframes) == 0 {
+		return 0
+	}
+	return t.keyframes[len(t.keyframes)-1].Time - t.keyframes[0].Time
+}
+
+func (t *TrackObj) SetInterpolation(i Interpolation) error {
+	if !i.valid() {
+		return fmt.Errorf("SetInterpolation: unknown interpolation %q: %w",
+			i, utils.ErrInvalidParameter)
+	}
+	t.interpolation = i
+	return nil
+}
+
+func (t *TrackObj) GetInterpolation() Interpolation {
+	return t.interpolation
+}
+
+// SetLoop sets whether the track repeats after the last keyframe, the
+// time is clamped to the first and last keyframes if not looping.
+//
+// The looping track jumps from the last keyframe to the first one, so the
+// last keyframe should have the same pose as the first one, see CloseLoop.
+// The curve of the closed track is smooth across the seam.
+func (t *TrackObj) SetLoop(loop bool) {
+	t.loop = loop
+}
+
+func (t *TrackObj) IsLoop() bool {
+	return t.loop
+}
+
+// CloseLoop adds the copy of the first keyframe at the time after the last
+// keyframe, the segment from the last keyframe to the time closes the
+// looping track.
+func (t *TrackObj) CloseLoop(time float32) error {
+	n := len(t.keyframes)
+	if n < 2 || time <= t.keyframes[n-1].Time {
+		return fmt.Errorf("CloseLoop: invalid time %v: %w", time, utils.ErrInvalidParameter)
+	}
+	k := t.keyframes[0]
+	k.Time = time
+	if err := t.AddKeyframe(k); err != nil {
+		return fmt.Errorf("CloseLoop: %w", err)
+	}
+	return nil
+}
+
+// closed reports whether the track is looping and the last keyframe has
+// the same pose as the first one.
+func (t *TrackObj) closed() bool {
+	n := len(t.keyframes)
+	if !t.loop || n < 3 {
+		return false
+	}
+	first, last := t.keyframes[0], t.keyframes[n-1]
+	d := last.Position.Sub(&first.Position)
+	dot := first.Orientation.Dot(&last.Orientation)
+	return d.Len() < float32(closedPositionEpsilon) &&
+		abs32(dot) > 1-float32(closedOrientationEpsilon)
+}
+
+// Evaluate gets the interpolated camera pose at the time, the Ease and
+// handles of the returned keyframe are not set.
+func (t *TrackObj) Evaluate(time float32) (Keyframe, error) {
+	n := len(t.keyframes)
+	if n == 0 {
+		return Keyframe{}, fmt.Errorf("Evaluate: track is empty: %w", utils.ErrInvalidParameter)
+	}
+	first, last := t.keyframes[0], t.keyframes[n-1]
+	if d := t.GetDuration(); t.loop && d > 0 {
+		time = first.Time + float32(math.Mod(float64(time-first.Time), float64(d)))
+		if time < first.Time {
+			time += d
+		}
+	}
+	if n == 1 || time <= first.Time {
+		return t.pose(first, time), nil
+	}
+	if time >= last.Time {
+		return t.pose(last, time), nil
+	}
+
+	i := sort.Search(n, func(i int) bool {
+		return t.keyframes[i].Time > time
+	}) - 1
+	k1, k2 := t.keyframes[i], t.keyframes[i+1]
+	u := (time - k1.Time) / (k2.Time - k1.Time)
+	u = k1.Ease.Apply(u)
+
+	// the neighbours of the closed track wrap around the seam, skipping
+	// the last keyframe which is the same as the first one
+	k0, k3 := k1, k2
+	closed := t.closed()
+	if i > 0 {
+		k0 = t.keyframes[i-1]
+	} else if closed {
+		k0 = t.keyframes[n-2]
+	}
+	if i+2 < n {
+		k3 = t.keyframes[i+2]
+	} else if closed {
+		k3 = t.keyframes[1]
+	}
+	p0, p1, p2, p3 := k0.Position, k1.Position, k2.Position, k3.Position
+
+	var pos glm.Vec3
+	switch t.interpolation {
+	case InterpolationLinear:
+		pos = lerpVec3(p1, p2, u)
+	case InterpolationBezier:
+		// the default handles make the same curve as Catmull-Rom
+		out := p2.Sub(&p0)
+		out = out.Mul(1.0 / 6)
+		if k1.OutHandle != nil {
+			out = *k1.OutHandle
+		}
+		in := p3.Sub(&p1)
+		in = in.Mul(-1.0 / 6)
+		if k2.InHandle != nil {
+			in = *k2.InHandle
+		}
+		pos = bezier(p1, p1.Add(&out), p2.Add(&in), p2, u)
+	default:
+		pos = catmullRom(p0, p1, p2, p3, u)
+	}
+
+	z1, z2 := zoomOf(k1), zoomOf(k2)
+	return Keyframe{
+		Time:        time,
+		Position:    pos,
+		Orientation: Slerp(k1.Orientation, k2.Orientation, u),
+		Zoom:        z1 + (z2-z1)*u,
+	}, nil
+}
+
+// Sample evaluates the track at the time and applies the pose to the
+// camera. The cameras without roll (CameraObj) only follow the yaw and
+// pitch of the orientation.
+func (t *TrackObj) Sample(time float32, cam aperture.Camera) error {
+	if cam == nil {
+		return fmt.Errorf("Sample: %w", utils.ErrInvalidPointer)
+	}
+	k, err := t.Evaluate(time)
+	if err != nil {
+		return fmt.Errorf("Sample: %w", err)
+	}
+	cam.SetPosition(k.Position)
+	setOrientation(cam, k.Orientation)
+	cam.SetZoom(k.Zoom)
+	return nil
+}
+
+func (t *TrackObj) MarshalJSON() ([]byte, error) {
+	return json.Marshal(trackJSON{
+		Interpolation: t.interpolation,
+		Loop:          t.loop,
+		Keyframes:     t.keyframes,
+	})
+}
+
+func (t *TrackObj) UnmarshalJSON(data []byte) error {
+	j := trackJSON{}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if err := t.Init(TrackInitParam{
+		Interpolation: j.Interpolation,
+		Loop:          j.Loop,
+	}); err != nil {
+		return err
+	}
+	for _, k := range j.Keyframes {
+		if err := t.AddKeyframe(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save saves the track to the JSON file.
+func (t *TrackObj) Save(file string) error {
+	if err := saveJSON(file, t); err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	return nil
+}
+
+// LoadTrack loads the track from the JSON file.
+func LoadTrack(file string) (*TrackObj, error) {
+	t := TrackObj{}
+	if err := loadJSON(file, &t); err != nil {
+		return nil, fmt.Errorf("LoadTrack: %w", err)
+	}
+	return &t, nil
+}
+
+func (i Interpolation) valid() bool {
+	switch i {
+	case InterpolationLinear, InterpolationCatmullRom, InterpolationBezier:
+		return true
+	}
+	return false
+}
+
+func (t *TrackObj) pose(k Keyframe, time float32) Keyframe {
+	return Keyframe{
+		Time:        time,
+		Position:    k.Position,
+		Orientation: k.Orientation,
+		Zoom:        zoomOf(k),
+	}
+}
+
+func zoomOf(k Keyframe) float32 {
+	if k.Zoom <= 0 {
+		return float32(defaultCameraZoom)
+	}
+	return k.Zoom
+}
+
+func lerpVec3(a, b glm.Vec3, t float32) glm.Vec3 {
+	d := b.Sub(&a)
+	a.AddScaledVec(t, &d)
+	return a
+}
+
+func catmullRom(p0, p1, p2, p3 glm.Vec3, t float32) glm.Vec3 {
+	t2, t3 := t*t, t*t*t
+	var out glm.Vec3
+	for i := range out {
+		out[i] = 0.5 * (2*p1[i] +
+			(p2[i]-p0[i])*t +
+			(2*p0[i]-5*p1[i]+4*p2[i]-p3[i])*t2 +
+			(3*p1[i]-p0[i]-3*p2[i]+p3[i])*t3)
+	}
+	return out
+}
+
+func bezier(p0, c0, c1, p1 glm.Vec3, t float32) glm.Vec3 {
+	s := 1 - t
+	var out glm.Vec3
+	for i := range out {
+		out[i] = s*s*s*p0[i] + 3*s*s*t*c0[i] + 3*s*t*t*c1[i] + t*t*t*p1[i]
+	}
+	return out
+}
+
+// getOrientation gets the orientation of the camera, the cameras without
+// the GetOrientation method are calculated from the front vector.
+func getOrientation(cam aperture.Camera) glm.Quat {
+	if o, ok := cam.(interface{ GetOrientation() glm.Quat }); ok {
+		return o.GetOrientation()
+	}
+	return lookRotation(cam.GetFront(), glm.Vec3{0, 1, 0})
+}
+
+// setOrientation sets the orientation of the camera, the cameras without
+// the SetOrientation method are set by the yaw and pitch of the front
+// vector.
+func setOrientation(cam aperture.Camera, q glm.Quat) {
+	if o, ok := cam.(interface{ SetOrientation(glm.Quat) }); ok {
+		o.SetOrientation(q)
+		return
+	}
+	front := q.Rotate(&glm.Vec3{0, 0, -1})
+	cam.SetYaw(glm.RadToDeg(float32(math.Atan2(float64(front.Z()), float64(front.X())))))
+	cam.SetPitch(glm.RadToDeg(float32(math.Asin(float64(glm.Clamp(front.Y(), -1, 1))))))
+}
+
+func NewTrackObj(p *TrackInitParam) (*TrackObj, error) {
+	t := TrackObj{}
+	if p == nil {
+		p = &TrackInitParam{}
+	}
+	if err := t.Init(*p); err != nil {
+		return nil, fmt.Errorf("NewTrackObj: %w", err)
+	}
+	return &t, nil
+}
@@ -0,0 +1,90 @@
+// Command glslgen generates the typed Go bindings of the GLSL shader program,
+// it is designed to be used with go generate:
+//
+//	//go:generate go run github.com/STARRY-S/aperture/cmd/glslgen -type PhongShader -vs phong.vs.glsl -fs phong.fs.glsl -o phong_gen.go
+//
+// The generated struct embeds aperture.Shader and has one typed setter
+// method per uniform, so the misspelled uniform names are caught at compile
+// time instead of the runtime error of Shader.Set.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/STARRY-S/aperture/shader/glsl"
+)
+
+func main() {
+	vs := flag.String("vs", "", "file path of vertex shader (required)")
+	fs := flag.String("fs", "", "file path of fragment shader (required)")
+	gs := flag.String("gs", "", "file path of geometry shader")
+	typ := flag.String("type", "", "name of the generated struct (required)")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "package name of the generated file")
+	out := flag.String("o", "", "output file path, default is <type>_gen.go")
+	flag.Parse()
+
+	if *vs == "" || *fs == "" || *typ == "" || *pkg == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *out == "" {
+		*out = strings.ToLower(*typ) + "_gen.go"
+	}
+
+	if err := run(*vs, *fs, *gs, *typ, *pkg, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "glslgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(vs, fs, gs, typ, pkg, out string) error {
+	decl, err := parseFile(vs, true)
+	if err != nil {
+		return err
+	}
+	for _, f := range []string{fs, gs} {
+		if f == "" {
+			continue
+		}
+		d, err := parseFile(f, false)
+		if err != nil {
+			return err
+		}
+		if err := decl.Merge(d); err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+	}
+
+	args := []string{"glslgen"}
+	for _, a := range os.Args[1:] {
+		args = append(args, filepath.ToSlash(a))
+	}
+	src, skipped, err := glsl.Generate(decl, glsl.GenerateParam{
+		Package: pkg,
+		Type:    typ,
+		Command: strings.Join(args, " "),
+	})
+	if err != nil {
+		return err
+	}
+	for _, s := range skipped {
+		fmt.Fprintf(os.Stderr, "glslgen: skipped uniform %q: type not supported by Shader.Set\n", s)
+	}
+	return os.WriteFile(out, src, 0644)
+}
+
+func parseFile(f string, vertex bool) (*glsl.Declarations, error) {
+	data, err := os.ReadFile(f)
+	if err != nil {
+		return nil, err
+	}
+	d, err := glsl.Parse(string(data), vertex)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f, err)
+	}
+	return d, nil
+}
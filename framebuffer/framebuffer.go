@@ -0,0 +1,542 @@
+// Package framebuffer has the built-in implemented framebuffer object,
+// it is used to render to textures, e.g. shadow maps and post-processing.
+package framebuffer
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/STARRY-S/aperture/texture"
+	"github.com/STARRY-S/aperture/texture/codec"
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// FramebufferObj is the OpenGL framebuffer object with any number of color
+// attachments and an optional depth (or depth-stencil) attachment.
+type FramebufferObj struct {
+	id      uint32
+	width   int32
+	height  int32
+	samples int32
+
+	colors []attachment
+	depth  *attachment
+
+	// prevFramebuffer and prevViewport are saved by Bind and restored
+	// by Unbind
+	prevFramebuffer int32
+	prevViewport    [4]int32
+	bound           bool
+
+	initialized bool
+}
+
+// AttachmentParam is used for customize the attachment of the framebuffer.
+type AttachmentParam struct {
+	// Format is the internal format of the attachment, default is
+	// texture.FormatRGBA8 for the color attachments and
+	// texture.FormatDepth24Stencil8 for the depth attachment.
+	Format texture.Format
+	// Renderbuffer uses the renderbuffer instead of the texture, the
+	// renderbuffer can not be sampled but may be faster. The attachments
+	// of the multisample framebuffer are always renderbuffers.
+	Renderbuffer bool
+	// TextureParam is the sampling parameters of the texture attachment,
+	// the Format of it is ignored.
+	TextureParam *texture.TextureParam
+}
+
+// FramebufferInitParam is used for customize the parameters when init
+// framebuffer.
+type FramebufferInitParam struct {
+	Width  int
+	Height int
+	// Samples is the sample count of MSAA, 0 or 1 disables MSAA. The
+	// multisample framebuffer is resolved to the single sample framebuffer
+	// by Resolve before sampling the textures.
+	Samples int
+	// Colors are the color attachments, GL_COLOR_ATTACHMENT0 + index.
+	Colors []AttachmentParam
+	// Depth is the depth attachment, the attachment with depth-stencil
+	// format is attached to GL_DEPTH_STENCIL_ATTACHMENT. Nil for no depth.
+	Depth *AttachmentParam
+}
+
+type attachment struct {
+	param        AttachmentParam
+	texture      *texture.TextureObj
+	renderbuffer uint32
+}
+
+// Init creates the framebuffer and the attachments,
+// this method requires an active OpenGL context.
+func (f *FramebufferObj) Init(initParam interface{}) error {
+	if f == nil {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidPointer)
+	}
+
+	if initParam == nil {
+		initParam = FramebufferInitParam{}
+	}
+	p, ok := initParam.(FramebufferInitParam)
+	if !ok {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidDataType)
+	}
+
+	if f.initialized {
+		return fmt.Errorf("Init: %w", utils.ErrReInitialize)
+	}
+
+	if p.Width <= 0 || p.Height <= 0 {
+		return fmt.Errorf("Init: invalid size %dx%d: %w",
+			p.Width, p.Height, utils.ErrInvalidParameter)
+	}
+	if len(p.Colors) == 0 && p.Depth == nil {
+		return fmt.Errorf("Init: no attachment: %w", utils.ErrInvalidParameter)
+	}
+	if p.Samples < 0 {
+		return fmt.Errorf("Init: invalid samples %d: %w",
+			p.Samples, utils.ErrInvalidParameter)
+	}
+	if p.Samples == 1 {
+		p.Samples = 0
+	}
+	var maxColors, maxSamples int32
+	gl.GetIntegerv(gl.MAX_COLOR_ATTACHMENTS, &maxColors)
+	gl.GetIntegerv(gl.MAX_SAMPLES, &maxSamples)
+	if int32(len(p.Colors)) > maxColors {
+		return fmt.Errorf("Init: %d color attachments exceed %d: %w",
+			len(p.Colors), maxColors, utils.ErrPositionExceed)
+	}
+	if int32(p.Samples) > maxSamples {
+		return fmt.Errorf("Init: %d samples exceed %d: %w",
+			p.Samples, maxSamples, utils.ErrPositionExceed)
+	}
+
+	f.colors = nil
+	for _, c := range p.Colors {
+		if c.Format == texture.FormatAuto {
+			c.Format = texture.FormatRGBA8
+		}
+		if c.Format.IsDepth() {
+			return fmt.Errorf("Init: color attachment with depth format: %w",
+				utils.ErrInvalidParameter)
+		}
+		f.colors = append(f.colors, attachment{param: c})
+	}
+	f.depth = nil
+	if p.Depth != nil {
+		d := *p.Depth
+		if d.Format == texture.FormatAuto {
+			d.Format = texture.FormatDepth24Stencil8
+		}
+		if !d.Format.IsDepth() {
+			return fmt.Errorf("Init: depth attachment without depth format: %w",
+				utils.ErrInvalidParameter)
+		}
+		f.depth = &attachment{param: d}
+	}
+	f.samples = int32(p.Samples)
+
+	gl.GenFramebuffers(1, &f.id)
+	if f.id == 0 {
+		return fmt.Errorf("Init: failed to create framebuffer")
+	}
+	f.initialized = true
+	if err := f.create(int32(p.Width), int32(p.Height)); err != nil {
+		f.Release()
+		return fmt.Errorf("Init: %w", err)
+	}
+	return nil
+}
+
+// create creates the attachments in the size and checks the completeness.
+func (f *FramebufferObj) create(width, height int32) error {
+	var prev int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &prev)
+	defer gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(prev))
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.id)
+
+	f.width, f.height = width, height
+	for i := range f.colors {
+		err := f.attach(&f.colors[i], gl.COLOR_ATTACHMENT0+uint32(i))
+		if err != nil {
+			return err
+		}
+	}
+	if f.depth != nil {
+		var point uint32 = gl.DEPTH_ATTACHMENT
+		if f.depth.param.Format.IsStencil() {
+			point = gl.DEPTH_STENCIL_ATTACHMENT
+		}
+		if err := f.attach(f.depth, point); err != nil {
+			return err
+		}
+	}
+	f.setDrawBuffers()
+
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("%s (0x%X): %w",
+			statusDescription(status), status, utils.ErrIncompleteFramebuffer)
+	}
+	return nil
+}
+
+// attach creates the texture or renderbuffer of the attachment and
+// attaches it to the bound framebuffer.
+func (f *FramebufferObj) attach(a *attachment, point uint32) error {
+	if a.param.Renderbuffer || f.samples > 0 {
+		gl.GenRenderbuffers(1, &a.renderbuffer)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, a.renderbuffer)
+		if f.samples > 0 {
+			gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, f.samples,
+				uint32(a.param.Format), f.width, f.height)
+		} else {
+			gl.RenderbufferStorage(gl.RENDERBUFFER,
+				uint32(a.param.Format), f.width, f.height)
+		}
+		gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, point, gl.RENDERBUFFER, a.renderbuffer)
+		return nil
+	}
+
+	var tp texture.TextureParam
+	if a.param.TextureParam != nil {
+		tp = *a.param.TextureParam
+	}
+	tp.Format = a.param.Format
+	t, err := texture.NewTextureObj(&tp)
+	if err != nil {
+		return err
+	}
+	if err := t.Alloc(int(f.width), int(f.height)); err != nil {
+		return err
+	}
+	a.texture = t
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, point, gl.TEXTURE_2D, t.GetID(), 0)
+	return nil
+}
+
+// setDrawBuffers sets the draw buffers of the bound framebuffer to all
+// color attachments, or none for the depth only framebuffer.
+func (f *FramebufferObj) setDrawBuffers() {
+	if len(f.colors) == 0 {
+		gl.DrawBuffer(gl.NONE)
+		gl.ReadBuffer(gl.NONE)
+		return
+	}
+	buffers := make([]uint32, len(f.colors))
+	for i := range buffers {
+		buffers[i] = gl.COLOR_ATTACHMENT0 + uint32(i)
+	}
+	gl.DrawBuffers(int32(len(buffers)), &buffers[0])
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+}
+
+// release deletes the textures and renderbuffers of the attachments.
+func (f *FramebufferObj) release() {
+	all := make([]*attachment, 0, len(f.colors)+1)
+	for i := range f.colors {
+		all = append(all, &f.colors[i])
+	}
+	if f.depth != nil {
+		all = append(all, f.depth)
+	}
+	for _, a := range all {
+		if a.texture != nil {
+			a.texture.Release()
+			a.texture = nil
+		}
+		if a.renderbuffer != 0 {
+			gl.DeleteRenderbuffers(1, &a.renderbuffer)
+			a.renderbuffer = 0
+		}
+	}
+}
+
+// Resize re-creates the attachments in the new size, the content of the
+// attachments is lost.
+func (f *FramebufferObj) Resize(width, height int) error {
+	if !f.initialized {
+		return fmt.Errorf("Resize: %w", utils.ErrInvalidPointer)
+	}
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("Resize: invalid size %dx%d: %w",
+			width, height, utils.ErrInvalidParameter)
+	}
+	if int32(width) == f.width && int32(height) == f.height {
+		return nil
+	}
+	f.release()
+	if err := f.create(int32(width), int32(height)); err != nil {
+		return fmt.Errorf("Resize: %w", err)
+	}
+	if f.bound {
+		gl.Viewport(0, 0, f.width, f.height)
+	}
+	return nil
+}
+
+// Bind binds the framebuffer and sets the viewport to the framebuffer
+// size, the previous framebuffer and viewport are restored by Unbind.
+func (f *FramebufferObj) Bind() {
+	if f.bound {
+		return
+	}
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &f.prevFramebuffer)
+	gl.GetIntegerv(gl.VIEWPORT, &f.prevViewport[0])
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.id)
+	gl.Viewport(0, 0, f.width, f.height)
+	f.bound = true
+}
+
+// Unbind restores the framebuffer (the default framebuffer of the window
+// if no other framebuffer was bound) and the viewport saved by Bind.
+func (f *FramebufferObj) Unbind() {
+	if !f.bound {
+		return
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(f.prevFramebuffer))
+	gl.Viewport(f.prevViewport[0], f.prevViewport[1],
+		f.prevViewport[2], f.prevViewport[3])
+	f.bound = false
+}
+
+// Blit copies the buffers in mask (e.g. gl.COLOR_BUFFER_BIT) to the dst
+// framebuffer, or the default framebuffer of the window if dst is nil, the
+// buffers are scaled by filter (gl.NEAREST or gl.LINEAR) if the sizes are
+// different. Each color attachment is copied to the color attachment of
+// the same index of dst, only the first one is copied to the default
+// framebuffer. The depth and stencil buffers require gl.NEAREST filter.
+//
+// The destination of the default framebuffer is the viewport of the
+// window, the viewport saved by Bind if the framebuffer is bound, or the
+// current viewport otherwise.
+func (f *FramebufferObj) Blit(dst *FramebufferObj, mask uint32, filter uint32) error {
+	if !f.initialized || (dst != nil && !dst.initialized) {
+		return fmt.Errorf("Blit: %w", utils.ErrInvalidPointer)
+	}
+	if mask&(gl.DEPTH_BUFFER_BIT|gl.STENCIL_BUFFER_BIT) != 0 && filter != gl.NEAREST {
+		return fmt.Errorf("Blit: depth and stencil require gl.NEAREST filter: %w",
+			utils.ErrInvalidParameter)
+	}
+	var dstID uint32
+	var x0, y0, x1, y1 int32
+	if dst != nil {
+		dstID, x1, y1 = dst.id, dst.width, dst.height
+	} else {
+		v := f.prevViewport
+		if !f.bound {
+			gl.GetIntegerv(gl.VIEWPORT, &v[0])
+		}
+		x0, y0, x1, y1 = v[0], v[1], v[0]+v[2], v[1]+v[3]
+	}
+
+	var prevRead, prevDraw, prevDrawBuffer int32
+	gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &prevRead)
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &prevDraw)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, f.id)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, dstID)
+	if dst == nil {
+		gl.GetIntegerv(gl.DRAW_BUFFER, &prevDrawBuffer)
+	}
+
+	if mask&gl.COLOR_BUFFER_BIT != 0 {
+		for i := range f.colors {
+			if dst == nil {
+				if i > 0 {
+					break
+				}
+				gl.DrawBuffer(gl.BACK)
+			} else {
+				if i >= len(dst.colors) {
+					break
+				}
+				gl.DrawBuffer(gl.COLOR_ATTACHMENT0 + uint32(i))
+			}
+			gl.ReadBuffer(gl.COLOR_ATTACHMENT0 + uint32(i))
+			gl.BlitFramebuffer(0, 0, f.width, f.height,
+				x0, y0, x1, y1, gl.COLOR_BUFFER_BIT, filter)
+		}
+		gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+		if dst != nil {
+			dst.setDrawBuffers()
+		} else {
+			gl.DrawBuffer(uint32(prevDrawBuffer))
+		}
+	}
+	if m := mask &^ gl.COLOR_BUFFER_BIT; m != 0 {
+		gl.BlitFramebuffer(0, 0, f.width, f.height,
+			x0, y0, x1, y1, m, gl.NEAREST)
+	}
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(prevRead))
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, uint32(prevDraw))
+	return nil
+}
+
+// Resolve resolves the multisample framebuffer to the single sample
+// framebuffer dst, or the default framebuffer if dst is nil, it copies
+// the color attachments and the depth attachment if both have it.
+// OpenGL requires the same size of the multisample framebuffer and the
+// destination, see Blit for the destination of the default framebuffer.
+func (f *FramebufferObj) Resolve(dst *FramebufferObj) error {
+	var mask uint32 = gl.COLOR_BUFFER_BIT
+	if dst != nil && f.depth != nil && dst.depth != nil {
+		mask |= gl.DEPTH_BUFFER_BIT
+	}
+	if err := f.Blit(dst, mask, gl.NEAREST); err != nil {
+		return fmt.Errorf("Resolve: %w", err)
+	}
+	return nil
+}
+
+// ReadPixels reads the color attachment i to the image, the rows are
+// flipped so the first row of the image is the top of the rendered image.
+// The float attachments are read as *codec.RGBAF32, others are read as
+// *image.RGBA. The multisample framebuffer should be resolved first.
+func (f *FramebufferObj) ReadPixels(i int) (image.Image, error) {
+	if !f.initialized {
+		return nil, fmt.Errorf("ReadPixels: %w", utils.ErrInvalidPointer)
+	}
+	if i < 0 || i >= len(f.colors) {
+		return nil, fmt.Errorf("ReadPixels: color attachment %d not exists: %w",
+			i, utils.ErrInvalidParameter)
+	}
+	if f.samples > 0 {
+		return nil, fmt.Errorf("ReadPixels: multisample framebuffer requires Resolve: %w",
+			utils.ErrInvalidParameter)
+	}
+
+	var prevRead int32
+	gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &prevRead)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, f.id)
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0 + uint32(i))
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	defer func() {
+		gl.PixelStorei(gl.PACK_ALIGNMENT, 4)
+		gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(prevRead))
+	}()
+
+	width, height := int(f.width), int(f.height)
+	rect := image.Rect(0, 0, width, height)
+	if f.colors[i].param.Format.IsFloat() {
+		img := codec.NewRGBAF32(rect)
+		gl.ReadPixels(0, 0, f.width, f.height, gl.RGBA, gl.FLOAT, gl.Ptr(img.Pix))
+		flipRows(img.Pix, img.Stride, height)
+		return img, nil
+	}
+	img := image.NewRGBA(rect)
+	gl.ReadPixels(0, 0, f.width, f.height, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	flipRows(img.Pix, img.Stride, height)
+	return img, nil
+}
+
+// Save reads the color attachment i and saves it to the image file, see
+// texture.SaveImage for the supported formats.
+func (f *FramebufferObj) Save(i int, file string) error {
+	img, err := f.ReadPixels(i)
+	if err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	if err := texture.SaveImage(file, img); err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	return nil
+}
+
+// flipRows flips the rows of the pixels vertically.
+func flipRows[T uint8 | float32](pix []T, stride, height int) {
+	row := make([]T, stride)
+	for y := 0; y < height/2; y++ {
+		top := pix[y*stride : (y+1)*stride]
+		bottom := pix[(height-1-y)*stride : (height-y)*stride]
+		copy(row, top)
+		copy(top, bottom)
+		copy(bottom, row)
+	}
+}
+
+// GetColorTexture gets the texture of the color attachment,
+// it returns nil if the attachment is a renderbuffer.
+func (f *FramebufferObj) GetColorTexture(i int) *texture.TextureObj {
+	if i < 0 || i >= len(f.colors) {
+		return nil
+	}
+	return f.colors[i].texture
+}
+
+// GetDepthTexture gets the texture of the depth attachment,
+// it returns nil if the attachment is a renderbuffer or not exists.
+func (f *FramebufferObj) GetDepthTexture() *texture.TextureObj {
+	if f.depth == nil {
+		return nil
+	}
+	return f.depth.texture
+}
+
+// GetColorCount gets the count of the color attachments.
+func (f *FramebufferObj) GetColorCount() int {
+	return len(f.colors)
+}
+
+func (f *FramebufferObj) GetSize() (width, height int32) {
+	return f.width, f.height
+}
+
+func (f *FramebufferObj) GetSamples() int32 {
+	return f.samples
+}
+
+func (f *FramebufferObj) GetID() uint32 {
+	return f.id
+}
+
+// Release deletes the framebuffer and the attachments.
+func (f *FramebufferObj) Release() {
+	if !f.initialized {
+		return
+	}
+	f.Unbind()
+	f.release()
+	gl.DeleteFramebuffers(1, &f.id)
+	f.id = 0
+	f.initialized = false
+}
+
+// statusDescription describes the framebuffer status.
+func statusDescription(status uint32) string {
+	switch status {
+	case gl.FRAMEBUFFER_UNDEFINED:
+		return "default framebuffer does not exist"
+	case gl.FRAMEBUFFER_INCOMPLETE_ATTACHMENT:
+		return "attachment is incomplete"
+	case gl.FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT:
+		return "no image attached"
+	case gl.FRAMEBUFFER_INCOMPLETE_DRAW_BUFFER:
+		return "draw buffer has no attachment"
+	case gl.FRAMEBUFFER_INCOMPLETE_READ_BUFFER:
+		return "read buffer has no attachment"
+	case gl.FRAMEBUFFER_UNSUPPORTED:
+		return "combination of internal formats is not supported"
+	case gl.FRAMEBUFFER_INCOMPLETE_MULTISAMPLE:
+		return "sample counts of attachments are different"
+	case gl.FRAMEBUFFER_INCOMPLETE_LAYER_TARGETS:
+		return "attachments are not all layered"
+	}
+	return "unknown status"
+}
+
+func NewFramebufferObj(p *FramebufferInitParam) (*FramebufferObj, error) {
+	f := FramebufferObj{}
+	if p == nil {
+		p = &FramebufferInitParam{}
+	}
+	if err := f.Init(*p); err != nil {
+		return nil, fmt.Errorf("NewFramebufferObj: %w", err)
+	}
+	return &f, nil
+}
@@ -0,0 +1,186 @@
+package framebuffer_test
+
+import (
+	"errors"
+	"image"
+	"path/filepath"
+	"testing"
+
+	"github.com/STARRY-S/aperture/framebuffer"
+	"github.com/STARRY-S/aperture/renderer"
+	"github.com/STARRY-S/aperture/texture"
+	"github.com/STARRY-S/aperture/texture/codec"
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/STARRY-S/aperture/window"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestFramebuffer(t *testing.T) {
+	renderer.InitAll()
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf("failed to init renderer")
+	}
+	win, err := window.NewWindowObj(&window.WindowInitParam{})
+	r.AppendWindow(win)
+
+	if _, err := framebuffer.NewFramebufferObj(&framebuffer.FramebufferInitParam{
+		Width:  64,
+		Height: 64,
+	}); !errors.Is(err, utils.ErrInvalidParameter) {
+		t.Errorf("framebuffer without attachment should return error")
+	}
+	if _, err := framebuffer.NewFramebufferObj(&framebuffer.FramebufferInitParam{
+		Width:  64,
+		Height: 64,
+		Colors: []framebuffer.AttachmentParam{{Format: texture.FormatDepth24}},
+	}); err == nil {
+		t.Errorf("color attachment with depth format should return error")
+	}
+
+	// G-buffer like framebuffer with two color textures and depth
+	fb, err := framebuffer.NewFramebufferObj(&framebuffer.FramebufferInitParam{
+		Width:  64,
+		Height: 32,
+		Colors: []framebuffer.AttachmentParam{
+			{},
+			{Format: texture.FormatRGBA16F},
+		},
+		Depth: &framebuffer.AttachmentParam{Renderbuffer: true},
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if fb.GetColorCount() != 2 || fb.GetColorTexture(1) == nil {
+		t.Errorf("invalid color attachments")
+	}
+	if fb.GetDepthTexture() != nil {
+		t.Errorf("depth renderbuffer should not be texture")
+	}
+
+	var viewport [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	fb.Bind()
+	var bound int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &bound)
+	if uint32(bound) != fb.GetID() {
+		t.Errorf("framebuffer not bound")
+	}
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	fb.Unbind()
+	var restored [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &restored[0])
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &bound)
+	if bound != 0 || restored != viewport {
+		t.Errorf("default framebuffer and viewport not restored")
+	}
+
+	// shadow map with depth texture only
+	shadow, err := framebuffer.NewFramebufferObj(&framebuffer.FramebufferInitParam{
+		Width:  128,
+		Height: 128,
+		Depth: &framebuffer.AttachmentParam{
+			Format: texture.FormatDepth24,
+			TextureParam: &texture.TextureParam{
+				CompareFunc: gl.LEQUAL,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if shadow.GetDepthTexture() == nil {
+		t.Errorf("depth texture should not be nil")
+	}
+	if err := shadow.Resize(256, 256); err != nil {
+		t.Errorf(err.Error())
+	}
+	if w, h := shadow.GetSize(); w != 256 || h != 256 {
+		t.Errorf("invalid size after resized")
+	}
+
+	// MSAA framebuffer resolved to the texture framebuffer
+	msaa, err := framebuffer.NewFramebufferObj(&framebuffer.FramebufferInitParam{
+		Width:   64,
+		Height:  32,
+		Samples: 4,
+		Colors:  []framebuffer.AttachmentParam{{}, {Format: texture.FormatRGBA16F}},
+		Depth:   &framebuffer.AttachmentParam{},
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := msaa.Resolve(fb); err != nil {
+		t.Errorf(err.Error())
+	}
+	if err := msaa.Blit(nil, gl.DEPTH_BUFFER_BIT, gl.LINEAR); err == nil {
+		t.Errorf("blit depth with linear filter should return error")
+	}
+	if _, err := msaa.ReadPixels(0); err == nil {
+		t.Errorf("read multisample framebuffer should return error")
+	}
+	if e := gl.GetError(); e != gl.NO_ERROR {
+		t.Errorf("GL error 0x%X", e)
+	}
+
+	// read back the cleared color of the attachments
+	fb.Bind()
+	gl.ClearColor(1, 0, 0, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	fb.Unbind()
+	img, err := fb.ReadPixels(0)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if c := img.(*image.RGBA).RGBAAt(3, 3); c.R != 255 || c.G != 0 {
+		t.Errorf("invalid color %v", c)
+	}
+	img, err = fb.ReadPixels(1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if _, ok := img.(*codec.RGBAF32); !ok {
+		t.Errorf("float attachment should be read as float image")
+	}
+	dir := t.TempDir()
+	if err := fb.Save(0, filepath.Join(dir, "color.png")); err != nil {
+		t.Errorf(err.Error())
+	}
+	if err := fb.Save(1, filepath.Join(dir, "color.hdr")); err != nil {
+		t.Errorf(err.Error())
+	}
+
+	// blit to the default framebuffer is scaled to the viewport
+	gl.ClearColor(0, 0, 0, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	var drawBuffer int32
+	gl.GetIntegerv(gl.DRAW_BUFFER, &drawBuffer)
+	if err := fb.Blit(nil, gl.COLOR_BUFFER_BIT, gl.NEAREST); err != nil {
+		t.Errorf(err.Error())
+	}
+	var restoredBuffer int32
+	gl.GetIntegerv(gl.DRAW_BUFFER, &restoredBuffer)
+	if restoredBuffer != drawBuffer {
+		t.Errorf("draw buffer not restored")
+	}
+	var pixel [4]uint8
+	gl.ReadBuffer(uint32(drawBuffer))
+	gl.ReadPixels(viewport[0]+viewport[2]-1, viewport[1]+viewport[3]-1, 1, 1,
+		gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(&pixel[0]))
+	if pixel[0] != 255 || pixel[1] != 0 {
+		t.Errorf("blit not scaled to the viewport, invalid color %v", pixel)
+	}
+	if e := gl.GetError(); e != gl.NO_ERROR {
+		t.Errorf("GL error 0x%X", e)
+	}
+
+	msaa.Release()
+	shadow.Release()
+	fb.Release()
+	r.Release()
+	renderer.TerminateAll()
+}
@@ -0,0 +1,129 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/STARRY-S/aperture"
+	"github.com/engoengine/glm"
+)
+
+// The indices of the frustum planes.
+const (
+	PlaneLeft = iota
+	PlaneRight
+	PlaneBottom
+	PlaneTop
+	PlaneNear
+	PlaneFar
+)
+
+// Frustum is the view frustum, the normals of the planes point to the
+// inside of the frustum.
+type Frustum struct {
+	Planes [6]Plane
+}
+
+// Bounded is the object having an axis-aligned bounding box in the world
+// coordinates, it can be filtered by the frustum.
+type Bounded interface {
+	GetBounds() AABB
+}
+
+// NewFrustum extracts the frustum planes from the view-projection matrix
+// (Gribb-Hartmann method), the planes are in the world coordinates. If the
+// matrix is the projection matrix, the planes are in the view coordinates.
+func NewFrustum(vp glm.Mat4) Frustum {
+	// the rows of the column-major matrix
+	var rows [4]glm.Vec4
+	for i := range rows {
+		rows[i] = glm.Vec4{vp[i], vp[4+i], vp[8+i], vp[12+i]}
+	}
+	plane := func(v glm.Vec4) Plane {
+		return Plane{Normal: glm.Vec3{v[0], v[1], v[2]}, D: v[3]}.Normalized()
+	}
+
+	f := Frustum{}
+	f.Planes[PlaneLeft] = plane(rows[3].Add(&rows[0]))
+	f.Planes[PlaneRight] = plane(rows[3].Sub(&rows[0]))
+	f.Planes[PlaneBottom] = plane(rows[3].Add(&rows[1]))
+	f.Planes[PlaneTop] = plane(rows[3].Sub(&rows[1]))
+	f.Planes[PlaneNear] = plane(rows[3].Add(&rows[2]))
+	f.Planes[PlaneFar] = plane(rows[3].Sub(&rows[2]))
+	return f
+}
+
+// NewCameraFrustum extracts the frustum of the camera, the far plane is
+// the view distance of the camera.
+func NewCameraFrustum(cam aperture.Camera) Frustum {
+	return NewFrustum(cam.GetViewProjectionMatrix())
+}
+
+// TestPoint tests whether the point is inside the frustum, the points on
+// the planes are Intersecting.
+func (f *Frustum) TestPoint(p glm.Vec3) Result {
+	result := Inside
+	for _, plane := range f.Planes {
+		d := plane.Distance(p)
+		if d < 0 {
+			return Outside
+		}
+		if d == 0 {
+			result = Intersecting
+		}
+	}
+	return result
+}
+
+// TestSphere tests the sphere against the frustum.
+func (f *Frustum) TestSphere(s Sphere) Result {
+	result := Inside
+	for _, plane := range f.Planes {
+		d := plane.Distance(s.Center)
+		if d < -s.Radius {
+			return Outside
+		}
+		if d < s.Radius {
+			result = Intersecting
+		}
+	}
+	return result
+}
+
+// TestAABB tests the axis-aligned bounding box against the frustum.
+//
+// Like most frustum culling, the test is conservative: the large box
+// near the corners of the frustum may be reported as Intersecting while
+// it is outside.
+func (f *Frustum) TestAABB(b AABB) Result {
+	c, e := b.Center(), b.Extents()
+	result := Inside
+	for _, plane := range f.Planes {
+		n := plane.Normal
+		// the projected radius of the box on the plane normal
+		r := e[0]*abs(n[0]) + e[1]*abs(n[1]) + e[2]*abs(n[2])
+		d := plane.Distance(c)
+		if d < -r {
+			return Outside
+		}
+		if d < r {
+			result = Intersecting
+		}
+	}
+	return result
+}
+
+// Filter gets the objects whose bounding boxes are not outside the
+// frustum, the order of the objects is kept.
+func Filter[T Bounded](f *Frustum, objects []T) []T {
+	visible := make([]T, 0, len(objects))
+	for _, o := range objects {
+		if f.TestAABB(o.GetBounds()) != Outside {
+			visible = append(visible, o)
+		}
+	}
+	return visible
+}
+
+func abs(v float32) float32 {
+	return float32(math.Abs(float64(v)))
+}
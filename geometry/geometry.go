@@ -0,0 +1,138 @@
+// Package geometry provides the bounding volumes and the intersection tests
+// used by culling and picking, all methods are pure math on glm types.
+package geometry
+
+import (
+	"math"
+
+	"github.com/engoengine/glm"
+)
+
+// Result is the result of testing a volume against another volume.
+type Result int
+
+const (
+	// Outside means the tested volume is completely outside.
+	Outside Result = iota
+	// Intersecting means the tested volume is partly inside.
+	Intersecting
+	// Inside means the tested volume is completely inside.
+	Inside
+)
+
+func (r Result) String() string {
+	switch r {
+	case Outside:
+		return "outside"
+	case Intersecting:
+		return "intersecting"
+	case Inside:
+		return "inside"
+	}
+	return "unknown"
+}
+
+// Plane is the plane of the points p with Normal·p + D = 0, the normal
+// points to the positive half space.
+type Plane struct {
+	Normal glm.Vec3
+	D      float32
+}
+
+// NewPlane creates the plane with the normal through the point.
+func NewPlane(normal, point glm.Vec3) Plane {
+	n := normal.Normalized()
+	return Plane{Normal: n, D: -n.Dot(&point)}
+}
+
+// Normalized gets the plane with the unit normal.
+func (p Plane) Normalized() Plane {
+	l := p.Normal.Len()
+	if l == 0 {
+		return p
+	}
+	return Plane{Normal: p.Normal.Mul(1 / l), D: p.D / l}
+}
+
+// Distance gets the signed distance from the point to the plane, the plane
+// should be normalized.
+func (p Plane) Distance(point glm.Vec3) float32 {
+	return p.Normal.Dot(&point) + p.D
+}
+
+// Sphere is the bounding sphere.
+type Sphere struct {
+	Center glm.Vec3
+	Radius float32
+}
+
+// AABB is the axis-aligned bounding box.
+type AABB struct {
+	Min glm.Vec3
+	Max glm.Vec3
+}
+
+// NewAABB creates the smallest box containing all points.
+func NewAABB(points ...glm.Vec3) AABB {
+	if len(points) == 0 {
+		return AABB{}
+	}
+	b := AABB{Min: points[0], Max: points[0]}
+	for _, p := range points[1:] {
+		b = b.Extend(p)
+	}
+	return b
+}
+
+// Extend gets the box enlarged to contain the point.
+func (b AABB) Extend(p glm.Vec3) AABB {
+	for i := 0; i < 3; i++ {
+		b.Min[i] = float32(math.Min(float64(b.Min[i]), float64(p[i])))
+		b.Max[i] = float32(math.Max(float64(b.Max[i]), float64(p[i])))
+	}
+	return b
+}
+
+// Center gets the center point of the box.
+func (b AABB) Center() glm.Vec3 {
+	c := b.Min.Add(&b.Max)
+	return c.Mul(0.5)
+}
+
+// Extents gets the half size of the box.
+func (b AABB) Extents() glm.Vec3 {
+	e := b.Max.Sub(&b.Min)
+	return e.Mul(0.5)
+}
+
+// Contains checks whether the point is inside the box.
+func (b AABB) Contains(p glm.Vec3) bool {
+	for i := 0; i < 3; i++ {
+		if p[i] < b.Min[i] || p[i] > b.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Transform gets the box containing the box transformed by the matrix.
+func (b AABB) Transform(m glm.Mat4) AABB {
+	var points [8]glm.Vec3
+	for i := range points {
+		p := glm.Vec4{b.Min[0], b.Min[1], b.Min[2], 1}
+		for j := 0; j < 3; j++ {
+			if i&(1<<j) != 0 {
+				p[j] = b.Max[j]
+			}
+		}
+		p = m.Mul4x1(&p)
+		points[i] = glm.Vec3{p[0], p[1], p[2]}
+	}
+	return NewAABB(points[:]...)
+}
+
+// Sphere gets the bounding sphere of the box.
+func (b AABB) Sphere() Sphere {
+	e := b.Extents()
+	return Sphere{Center: b.Center(), Radius: e.Len()}
+}
@@ -0,0 +1,147 @@
+package geometry_test
+
+import (
+	"testing"
+
+	"github.com/STARRY-S/aperture/camera"
+	"github.com/STARRY-S/aperture/geometry"
+	"github.com/engoengine/glm"
+	"github.com/stretchr/testify/assert"
+)
+
+type object struct {
+	name   string
+	bounds geometry.AABB
+}
+
+func (o object) GetBounds() geometry.AABB {
+	return o.bounds
+}
+
+func TestAABB(t *testing.T) {
+	b := geometry.NewAABB(glm.Vec3{1, 2, 3}, glm.Vec3{-1, 0, 5}, glm.Vec3{0, 4, 4})
+	assert.Equal(t, glm.Vec3{-1, 0, 3}, b.Min)
+	assert.Equal(t, glm.Vec3{1, 4, 5}, b.Max)
+	assert.Equal(t, glm.Vec3{0, 2, 4}, b.Center())
+	assert.Equal(t, glm.Vec3{1, 2, 1}, b.Extents())
+	assert.True(t, b.Contains(glm.Vec3{0, 1, 4}))
+	assert.False(t, b.Contains(glm.Vec3{0, 5, 4}))
+
+	moved := b.Transform(glm.Translate3D(1, 1, 1))
+	assert.Equal(t, glm.Vec3{0, 1, 4}, moved.Min)
+	assert.Equal(t, glm.Vec3{2, 5, 6}, moved.Max)
+
+	p := geometry.NewPlane(glm.Vec3{0, 2, 0}, glm.Vec3{0, 1, 0})
+	assert.Equal(t, float32(2), p.Distance(glm.Vec3{5, 3, 5}))
+}
+
+func TestFrustum(t *testing.T) {
+	// the camera at the origin looking at +X
+	c, _ := camera.NewCameraObj()
+	c.SetZoom(90)
+	c.SetNear(1)
+	c.SetFar(100)
+	f := geometry.NewCameraFrustum(c)
+
+	assert.Equal(t, geometry.Inside, f.TestPoint(glm.Vec3{10, 0, 0}))
+	assert.Equal(t, geometry.Outside, f.TestPoint(glm.Vec3{-10, 0, 0}))
+	assert.Equal(t, geometry.Outside, f.TestPoint(glm.Vec3{0.5, 0, 0}))
+	assert.Equal(t, geometry.Outside, f.TestPoint(glm.Vec3{101, 0, 0}))
+	assert.Equal(t, geometry.Outside, f.TestPoint(glm.Vec3{10, 11, 0}))
+
+	assert.Equal(t, geometry.Inside,
+		f.TestSphere(geometry.Sphere{Center: glm.Vec3{50, 0, 0}, Radius: 1}))
+	assert.Equal(t, geometry.Intersecting,
+		f.TestSphere(geometry.Sphere{Center: glm.Vec3{100, 0, 0}, Radius: 1}))
+	assert.Equal(t, geometry.Outside,
+		f.TestSphere(geometry.Sphere{Center: glm.Vec3{-5, 0, 0}, Radius: 1}))
+
+	inside := geometry.AABB{Min: glm.Vec3{10, -1, -1}, Max: glm.Vec3{12, 1, 1}}
+	crossing := geometry.AABB{Min: glm.Vec3{10, 5, -1}, Max: glm.Vec3{12, 15, 1}}
+	behind := geometry.AABB{Min: glm.Vec3{-12, -1, -1}, Max: glm.Vec3{-10, 1, 1}}
+	assert.Equal(t, geometry.Inside, f.TestAABB(inside))
+	assert.Equal(t, geometry.Intersecting, f.TestAABB(crossing))
+	assert.Equal(t, geometry.Outside, f.TestAABB(behind))
+	assert.Equal(t, "intersecting", geometry.Intersecting.String())
+
+	objects := []object{
+		{"inside", inside},
+		{"behind", behind},
+		{"crossing", crossing},
+	}
+	visible := geometry.Filter(&f, objects)
+	assert.Equal(t, []object{objects[0], objects[2]}, visible)
+
+	// the orthographic projection
+	c.SetProjectionMode(camera.ProjectionOrthographic)
+	c.SetOrthoSize(4)
+	f = geometry.NewCameraFrustum(c)
+	assert.Equal(t, geometry.Inside, f.TestPoint(glm.Vec3{50, 1.9, 1.9}))
+	assert.Equal(t, geometry.Outside, f.TestPoint(glm.Vec3{50, 2.1, 0}))
+}
+
+func TestRay(t *testing.T) {
+	// the camera at the origin looking at +X
+	c, _ := camera.NewCameraObj()
+	c.SetAspect(2)
+	r, err := geometry.ScreenRay(400, 200, 800, 400, c)
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float32{1, 0, 0}, r.Direction[:], 1e-5)
+	assert.InDeltaSlice(t, []float32{camera.DefaultNear, 0, 0}, r.Origin[:], 1e-5)
+
+	// the top-left corner of the window
+	c.SetZoom(90)
+	r, _ = geometry.ScreenRay(0, 0, 800, 400, c)
+	p := r.At((10 - r.Origin.X()) / r.Direction.X())
+	assert.InDeltaSlice(t, []float32{10, 10, -20}, p[:], 1e-3)
+	_, err = geometry.ScreenRay(0, 0, 0, 400, c)
+	assert.NotNil(t, err)
+
+	r = geometry.NewRay(glm.Vec3{0, 0, -5}, glm.Vec3{0, 0, 2})
+	hit, ok := r.IntersectTriangle(glm.Vec3{-1, -1, 0}, glm.Vec3{1, -1, 0}, glm.Vec3{0, 1, 0})
+	assert.True(t, ok)
+	assert.InDelta(t, 5, hit.Distance, 1e-5)
+	assert.InDeltaSlice(t, []float32{0, 0, 0}, hit.Point[:], 1e-5)
+	assert.InDeltaSlice(t, []float32{0, 0, -1}, hit.Normal[:], 1e-5)
+	_, ok = r.IntersectTriangle(glm.Vec3{1, 1, 0}, glm.Vec3{2, 1, 0}, glm.Vec3{1, 2, 0})
+	assert.False(t, ok)
+	// the tolerance of the parallel test is relative to the triangle size
+	hit, ok = r.IntersectTriangle(glm.Vec3{-1e-4, -1e-4, 0}, glm.Vec3{1e-4, -1e-4, 0}, glm.Vec3{0, 1e-4, 0})
+	assert.True(t, ok)
+	assert.InDelta(t, 5, hit.Distance, 1e-5)
+	_, ok = r.IntersectTriangle(glm.Vec3{0, 0, 0}, glm.Vec3{0, 0, 0}, glm.Vec3{0, 1, 0})
+	assert.False(t, ok)
+	parallel := geometry.NewRay(glm.Vec3{0, 0, -1}, glm.Vec3{1, 0, 0})
+	_, ok = parallel.IntersectTriangle(glm.Vec3{-1, -1, 0}, glm.Vec3{1, -1, 0}, glm.Vec3{0, 1, 0})
+	assert.False(t, ok)
+
+	b := geometry.AABB{Min: glm.Vec3{-1, -1, -1}, Max: glm.Vec3{1, 1, 1}}
+	hit, ok = r.IntersectAABB(b)
+	assert.True(t, ok)
+	assert.InDelta(t, 4, hit.Distance, 1e-5)
+	assert.InDeltaSlice(t, []float32{0, 0, -1}, hit.Normal[:], 1e-5)
+	inside := geometry.NewRay(glm.Vec3{0, 0, 0}, glm.Vec3{1, 0, 0})
+	hit, ok = inside.IntersectAABB(b)
+	assert.True(t, ok)
+	assert.InDelta(t, 1, hit.Distance, 1e-5)
+	assert.InDeltaSlice(t, []float32{1, 0, 0}, hit.Normal[:], 1e-5)
+	_, ok = geometry.NewRay(glm.Vec3{0, 2, -5}, glm.Vec3{0, 0, 1}).IntersectAABB(b)
+	assert.False(t, ok)
+
+	hit, ok = r.IntersectSphere(geometry.Sphere{Center: glm.Vec3{0, 0, 1}, Radius: 2})
+	assert.True(t, ok)
+	assert.InDelta(t, 4, hit.Distance, 1e-5)
+	assert.InDeltaSlice(t, []float32{0, 0, -1}, hit.Normal[:], 1e-5)
+	_, ok = r.IntersectSphere(geometry.Sphere{Center: glm.Vec3{0, 0, -10}, Radius: 2})
+	assert.False(t, ok)
+
+	ground := geometry.NewPlane(glm.Vec3{0, 1, 0}, glm.Vec3{0, -2, 0})
+	down := geometry.NewRay(glm.Vec3{1, 3, 1}, glm.Vec3{0, -1, 0})
+	hit, ok = down.IntersectPlane(ground)
+	assert.True(t, ok)
+	assert.InDelta(t, 5, hit.Distance, 1e-5)
+	assert.InDeltaSlice(t, []float32{1, -2, 1}, hit.Point[:], 1e-5)
+	assert.InDeltaSlice(t, []float32{0, 1, 0}, hit.Normal[:], 1e-5)
+	_, ok = r.IntersectPlane(ground)
+	assert.False(t, ok)
+}
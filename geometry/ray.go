@@ -0,0 +1,204 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/STARRY-S/aperture"
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/engoengine/glm"
+)
+
+// Ray is the half line from the origin along the unit direction.
+type Ray struct {
+	Origin    glm.Vec3
+	Direction glm.Vec3
+}
+
+// Hit is the intersection of the ray.
+type Hit struct {
+	// Distance is the distance from the ray origin to the point.
+	Distance float32
+	// Point is the intersection point in the world coordinates.
+	Point glm.Vec3
+	// Normal is the unit surface normal at the point, it faces the ray
+	// origin for the triangles and planes.
+	Normal glm.Vec3
+}
+
+// epsilon is the tolerance of the parallel ray tests.
+const epsilon = 1e-7
+
+// NewRay creates the ray, the direction is normalized.
+func NewRay(origin, direction glm.Vec3) Ray {
+	return Ray{Origin: origin, Direction: direction.Normalized()}
+}
+
+// ScreenRay unprojects the cursor position of the window into the world
+// ray from the camera, the cursor position is in the window coordinates
+// with the origin at the top-left corner, width and height are the window
+// size. The ray starts at the near plane of the camera.
+func ScreenRay(x, y float32, width, height int, cam aperture.Camera) (Ray, error) {
+	if cam == nil {
+		return Ray{}, fmt.Errorf("ScreenRay: %w", utils.ErrInvalidPointer)
+	}
+	if width <= 0 || height <= 0 {
+		return Ray{}, fmt.Errorf("ScreenRay: invalid window size %dx%d: %w",
+			width, height, utils.ErrInvalidParameter)
+	}
+	ndcX := 2*x/float32(width) - 1
+	ndcY := 1 - 2*y/float32(height)
+	ray, err := NDCRay(ndcX, ndcY, cam.GetViewProjectionMatrix())
+	if err != nil {
+		return Ray{}, fmt.Errorf("ScreenRay: %w", err)
+	}
+	return ray, nil
+}
+
+// NDCRay unprojects the point in the normalized device coordinates into
+// the world ray by the view-projection matrix.
+func NDCRay(x, y float32, vp glm.Mat4) (Ray, error) {
+	if vp.Det() == 0 {
+		return Ray{}, fmt.Errorf("NDCRay: singular matrix: %w", utils.ErrInvalidParameter)
+	}
+	inv := vp.Inverse()
+	unproject := func(z float32) glm.Vec3 {
+		p := inv.Mul4x1(&glm.Vec4{x, y, z, 1})
+		return glm.Vec3{p[0] / p[3], p[1] / p[3], p[2] / p[3]}
+	}
+	near, far := unproject(-1), unproject(1)
+	dir := far.Sub(&near)
+	if dir.Len2() == 0 {
+		return Ray{}, fmt.Errorf("NDCRay: %w", utils.ErrInvalidParameter)
+	}
+	return NewRay(near, dir), nil
+}
+
+// At gets the point at the distance along the ray.
+func (r Ray) At(t float32) glm.Vec3 {
+	p := r.Origin
+	p.AddScaledVec(t, &r.Direction)
+	return p
+}
+
+// IntersectTriangle tests the ray against the triangle by the
+// Möller-Trumbore algorithm, both sides of the triangle are hit.
+func (r Ray) IntersectTriangle(a, b, c glm.Vec3) (Hit, bool) {
+	e1, e2 := b.Sub(&a), c.Sub(&a)
+	p := r.Direction.Cross(&e2)
+	det := e1.Dot(&p)
+	// det is scaled by the edge lengths, the tolerance is relative to the
+	// size of the triangle so the small triangles are not rejected
+	if abs(det) <= epsilon*e1.Len()*e2.Len() {
+		return Hit{}, false
+	}
+	inv := 1 / det
+	s := r.Origin.Sub(&a)
+	u := s.Dot(&p) * inv
+	if u < 0 || u > 1 {
+		return Hit{}, false
+	}
+	q := s.Cross(&e1)
+	v := r.Direction.Dot(&q) * inv
+	if v < 0 || u+v > 1 {
+		return Hit{}, false
+	}
+	t := e2.Dot(&q) * inv
+	if t < 0 {
+		return Hit{}, false
+	}
+	n := e1.Cross(&e2)
+	return r.hit(t, n), true
+}
+
+// IntersectAABB tests the ray against the axis-aligned bounding box by the
+// slab method, the ray starting inside the box hits the exit point. The
+// normal points outwards.
+func (r Ray) IntersectAABB(b AABB) (Hit, bool) {
+	tMin := float32(math.Inf(-1))
+	tMax := float32(math.Inf(1))
+	var nMin, nMax glm.Vec3
+	for i := 0; i < 3; i++ {
+		if abs(r.Direction[i]) < epsilon {
+			if r.Origin[i] < b.Min[i] || r.Origin[i] > b.Max[i] {
+				return Hit{}, false
+			}
+			continue
+		}
+		inv := 1 / r.Direction[i]
+		t1 := (b.Min[i] - r.Origin[i]) * inv
+		t2 := (b.Max[i] - r.Origin[i]) * inv
+		// the outward normal of the entry face of the slab
+		var n glm.Vec3
+		n[i] = -1
+		if t1 > t2 {
+			t1, t2 = t2, t1
+			n[i] = 1
+		}
+		if t1 > tMin {
+			tMin, nMin = t1, n
+		}
+		if t2 < tMax {
+			tMax, nMax = t2, n.Mul(-1)
+		}
+		if tMin > tMax {
+			return Hit{}, false
+		}
+	}
+	if tMax < 0 {
+		return Hit{}, false
+	}
+	if tMin >= 0 {
+		return Hit{Distance: tMin, Point: r.At(tMin), Normal: nMin}, true
+	}
+	return Hit{Distance: tMax, Point: r.At(tMax), Normal: nMax}, true
+}
+
+// IntersectSphere tests the ray against the sphere, the ray starting
+// inside the sphere hits the exit point. The normal points outwards.
+func (r Ray) IntersectSphere(s Sphere) (Hit, bool) {
+	oc := r.Origin.Sub(&s.Center)
+	b := oc.Dot(&r.Direction)
+	c := oc.Len2() - s.Radius*s.Radius
+	disc := b*b - c
+	if disc < 0 {
+		return Hit{}, false
+	}
+	sq := float32(math.Sqrt(float64(disc)))
+	t := -b - sq
+	if t < 0 {
+		t = -b + sq
+	}
+	if t < 0 {
+		return Hit{}, false
+	}
+	p := r.At(t)
+	n := p.Sub(&s.Center)
+	if s.Radius > 0 {
+		n = n.Mul(1 / s.Radius)
+	}
+	return Hit{Distance: t, Point: p, Normal: n}, true
+}
+
+// IntersectPlane tests the ray against the plane, the plane should be
+// normalized.
+func (r Ray) IntersectPlane(p Plane) (Hit, bool) {
+	denom := p.Normal.Dot(&r.Direction)
+	if abs(denom) < epsilon {
+		return Hit{}, false
+	}
+	t := -p.Distance(r.Origin) / denom
+	if t < 0 {
+		return Hit{}, false
+	}
+	return r.hit(t, p.Normal), true
+}
+
+// hit gets the hit at the distance with the normal facing the ray origin.
+func (r Ray) hit(t float32, n glm.Vec3) Hit {
+	n.Normalize()
+	if n.Dot(&r.Direction) > 0 {
+		n = n.Mul(-1)
+	}
+	return Hit{Distance: t, Point: r.At(t), Normal: n}
+}
@@ -0,0 +1,275 @@
+// Package material has the built-in implemented material struct, a material
+// binds the shader program, uniform values and textures in one call.
+package material
+
+import (
+	"fmt"
+	"sort"
+
+	ap "github.com/STARRY-S/aperture"
+	"github.com/STARRY-S/aperture/shader"
+	"github.com/STARRY-S/aperture/texture"
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// The names of the standard texture slots, same as the AP_Texture_types of
+// the C library, they are also the sampler names used by built-in shaders.
+const (
+	SlotDiffuse  = "texture_diffuse"
+	SlotSpecular = "texture_specular"
+	SlotNormal   = "texture_normal"
+	SlotHeight   = "texture_height"
+)
+
+// targeter is implemented by the textures not using GL_TEXTURE_2D target.
+type targeter interface {
+	GetTarget() uint32
+}
+
+// MaterialObj implements the Material interface.
+type MaterialObj struct {
+	name string
+
+	shader ap.Shader
+	// variant and features are used to request the shader variant,
+	// the variant takes precedence over shader if set.
+	variant  *shader.VariantObj
+	features []string
+
+	uniforms map[string]interface{}
+	textures map[string]ap.Texture
+	samplers map[string]*texture.SamplerObj
+}
+
+// MaterialInitParam is used for customize the parameters when init material.
+type MaterialInitParam struct {
+	Name     string
+	Shader   ap.Shader
+	Uniforms map[string]interface{}
+	Textures map[string]ap.Texture
+}
+
+func (m *MaterialObj) Init(initParam interface{}) error {
+	if m == nil {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidPointer)
+	}
+
+	if initParam == nil {
+		initParam = MaterialInitParam{}
+	}
+	p, ok := initParam.(MaterialInitParam)
+	if !ok {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidDataType)
+	}
+
+	m.name = p.Name
+	m.shader = p.Shader
+	m.uniforms = make(map[string]interface{})
+	m.textures = make(map[string]ap.Texture)
+	m.samplers = make(map[string]*texture.SamplerObj)
+	for k, v := range p.Uniforms {
+		m.uniforms[k] = v
+	}
+	for k, v := range p.Textures {
+		m.SetTexture(k, v)
+	}
+	return nil
+}
+
+func (m *MaterialObj) SetShader(s ap.Shader) {
+	m.shader = s
+	m.variant = nil
+	m.features = nil
+}
+
+// GetShader gets the shader of the material, if the material uses a shader
+// variant, the variant will be compiled if it is not compiled yet.
+func (m *MaterialObj) GetShader() ap.Shader {
+	s, err := m.resolveShader()
+	if err != nil {
+		return nil
+	}
+	return s
+}
+
+// SetVariant sets the material to use the shader variant of the features.
+func (m *MaterialObj) SetVariant(v *shader.VariantObj, features ...string) {
+	m.variant = v
+	m.features = features
+	m.shader = nil
+}
+
+// GetFeatures gets the features of the shader variant.
+func (m *MaterialObj) GetFeatures() []string {
+	return m.features
+}
+
+// SetUniform sets the uniform value applied when binding the material,
+// the value types are the same as Shader.Set method.
+func (m *MaterialObj) SetUniform(name string, value interface{}) {
+	if m.uniforms == nil {
+		m.uniforms = make(map[string]interface{})
+	}
+	m.uniforms[name] = value
+}
+
+func (m *MaterialObj) GetUniform(name string) interface{} {
+	return m.uniforms[name]
+}
+
+// DeleteUniform removes the uniform value from the material.
+func (m *MaterialObj) DeleteUniform(name string) {
+	delete(m.uniforms, name)
+}
+
+// SetTexture sets the texture of the slot, the slot is the sampler uniform
+// name in the shader, e.g. SlotDiffuse. Set nil to remove the texture.
+func (m *MaterialObj) SetTexture(slot string, tex ap.Texture) {
+	if m.textures == nil {
+		m.textures = make(map[string]ap.Texture)
+	}
+	if tex == nil {
+		delete(m.textures, slot)
+		return
+	}
+	m.textures[slot] = tex
+}
+
+func (m *MaterialObj) GetTexture(slot string) ap.Texture {
+	return m.textures[slot]
+}
+
+// SetSampler sets the sampler object used by the texture of the slot,
+// it overrides the sampling parameters of the texture. Set nil to remove
+// the sampler.
+func (m *MaterialObj) SetSampler(slot string, sampler *texture.SamplerObj) {
+	if m.samplers == nil {
+		m.samplers = make(map[string]*texture.SamplerObj)
+	}
+	if sampler == nil {
+		delete(m.samplers, slot)
+		return
+	}
+	m.samplers[slot] = sampler
+}
+
+func (m *MaterialObj) GetSampler(slot string) *texture.SamplerObj {
+	return m.samplers[slot]
+}
+
+// GetTextureUnit gets the texture unit allocated for the slot,
+// the units are allocated by the sorted slot names starting from 0,
+// it returns -1 if the slot has no texture.
+func (m *MaterialObj) GetTextureUnit(slot string) int32 {
+	for i, s := range m.slots() {
+		if s == slot {
+			return int32(i)
+		}
+	}
+	return -1
+}
+
+// Bind uses the shader program, binds the textures to the allocated texture
+// units and sets all uniform values, the slots and uniforms not used by the
+// shader program are skipped.
+func (m *MaterialObj) Bind() error {
+	s, err := m.resolveShader()
+	if err != nil {
+		return fmt.Errorf("Bind: %w", err)
+	}
+	if s == nil || s.GetID() == 0 {
+		return fmt.Errorf("Bind: shader not initialized: %w", utils.ErrInvalidPointer)
+	}
+	gl.UseProgram(s.GetID())
+
+	slots := m.slots()
+	var maxUnits int32
+	gl.GetIntegerv(gl.MAX_COMBINED_TEXTURE_IMAGE_UNITS, &maxUnits)
+	if int32(len(slots)) > maxUnits {
+		return fmt.Errorf("Bind: %d textures exceed %d texture units: %w",
+			len(slots), maxUnits, utils.ErrPositionExceed)
+	}
+	for i, slot := range slots {
+		tex := m.textures[slot]
+		var target uint32 = gl.TEXTURE_2D
+		if t, ok := tex.(targeter); ok {
+			target = t.GetTarget()
+		}
+		gl.ActiveTexture(gl.TEXTURE0 + uint32(i))
+		gl.BindTexture(target, tex.GetID())
+		if sampler, ok := m.samplers[slot]; ok {
+			sampler.Bind(uint32(i))
+		} else {
+			gl.BindSampler(uint32(i), 0)
+		}
+		if err := setUniform(s, slot, int32(i)); err != nil {
+			return fmt.Errorf("Bind: %w", err)
+		}
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	names := make([]string, 0, len(m.uniforms))
+	for name := range m.uniforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := setUniform(s, name, m.uniforms[name]); err != nil {
+			return fmt.Errorf("Bind: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *MaterialObj) GetName() string {
+	return m.name
+}
+
+func (m *MaterialObj) SetName(name string) {
+	m.name = name
+}
+
+// setUniform sets the uniform value to the shader program, the uniforms
+// not active in the program (removed by the compiler or the disabled
+// features of the variant) are skipped.
+func setUniform(s ap.Shader, name string, value interface{}) error {
+	if gl.GetUniformLocation(s.GetID(), gl.Str(name+"\x00")) < 0 {
+		return nil
+	}
+	return s.Set(name, value)
+}
+
+// resolveShader gets the shader or the shader variant of the material.
+func (m *MaterialObj) resolveShader() (ap.Shader, error) {
+	if m.variant == nil {
+		return m.shader, nil
+	}
+	s, err := m.variant.Get(m.features...)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// slots gets the sorted slot names of the textures.
+func (m *MaterialObj) slots() []string {
+	slots := make([]string, 0, len(m.textures))
+	for s := range m.textures {
+		slots = append(slots, s)
+	}
+	sort.Strings(slots)
+	return slots
+}
+
+func NewMaterialObj(p *MaterialInitParam) (*MaterialObj, error) {
+	m := MaterialObj{}
+	if p == nil {
+		p = &MaterialInitParam{}
+	}
+	err := m.Init(*p)
+	if err != nil {
+		return nil, fmt.Errorf("NewMaterialObj: %w", err)
+	}
+	return &m, nil
+}
@@ -0,0 +1,122 @@
+package material_test
+
+import (
+	"testing"
+
+	"github.com/STARRY-S/aperture"
+	"github.com/STARRY-S/aperture/material"
+	"github.com/STARRY-S/aperture/renderer"
+	"github.com/STARRY-S/aperture/shader"
+	"github.com/STARRY-S/aperture/texture"
+	"github.com/STARRY-S/aperture/window"
+	"github.com/engoengine/glm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterface(t *testing.T) {
+	m, err := material.NewMaterialObj(&material.MaterialInitParam{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	var mat aperture.Material
+	mat = m
+	if mat.GetShader() != nil {
+		t.Errorf("GetShader should be nil")
+	}
+	if err := mat.Bind(); err == nil {
+		t.Errorf("Bind without shader should return error")
+	}
+
+	if _, err := material.NewMaterialObj(nil); err != nil {
+		t.Errorf(err.Error())
+	}
+}
+
+func TestSetterGetter(t *testing.T) {
+	s := &shader.ShaderObj{}
+	m, err := material.NewMaterialObj(&material.MaterialInitParam{
+		Name:   "Test",
+		Shader: s,
+		Uniforms: map[string]interface{}{
+			"shininess": float32(32),
+		},
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, "Test", m.GetName())
+	assert.Equal(t, float32(32), m.GetUniform("shininess"))
+	m.SetUniform("color", glm.Vec4{1, 1, 1, 1})
+	assert.Equal(t, glm.Vec4{1, 1, 1, 1}, m.GetUniform("color"))
+	m.DeleteUniform("color")
+	assert.Nil(t, m.GetUniform("color"))
+
+	diffuse := &texture.TextureObj{}
+	normal := &texture.TextureObj{}
+	m.SetTexture(material.SlotNormal, normal)
+	m.SetTexture(material.SlotDiffuse, diffuse)
+	assert.Equal(t, diffuse, m.GetTexture(material.SlotDiffuse))
+	// texture units are allocated by sorted slot names
+	assert.Equal(t, int32(0), m.GetTextureUnit(material.SlotDiffuse))
+	assert.Equal(t, int32(1), m.GetTextureUnit(material.SlotNormal))
+	assert.Equal(t, int32(-1), m.GetTextureUnit(material.SlotSpecular))
+	sampler := &texture.SamplerObj{}
+	m.SetSampler(material.SlotNormal, sampler)
+	assert.Equal(t, sampler, m.GetSampler(material.SlotNormal))
+	m.SetSampler(material.SlotNormal, nil)
+	assert.Nil(t, m.GetSampler(material.SlotNormal))
+	m.SetTexture(material.SlotDiffuse, nil)
+	assert.Equal(t, int32(0), m.GetTextureUnit(material.SlotNormal))
+
+	v, err := shader.NewVariantObj("vs", "fs", "", []string{"FOG"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	m.SetVariant(v, "UNKNOWN")
+	assert.Equal(t, []string{"UNKNOWN"}, m.GetFeatures())
+	if err := m.Bind(); err == nil {
+		t.Errorf("Bind with undeclared feature should return error")
+	}
+}
+
+func TestBind(t *testing.T) {
+	renderer.InitAll()
+
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	w, err := window.NewWindowObj(&window.WindowInitParam{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	r.AppendWindow(w)
+
+	s := &shader.ShaderObj{}
+	err = s.Load("../shader/test/vertex.glsl", "../shader/test/fragment.glsl", "")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	// the test shader has no samplers and no "shininess" uniform
+	m, err := material.NewMaterialObj(&material.MaterialInitParam{
+		Shader: s,
+		Uniforms: map[string]interface{}{
+			"shininess": float32(32),
+		},
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	m.SetTexture(material.SlotNormal, &texture.TextureObj{})
+	if err := m.Bind(); err != nil {
+		t.Errorf("Bind with unused slot and uniform: %v", err)
+	}
+
+	s.Release()
+	r.Release()
+	renderer.TerminateAll()
+}
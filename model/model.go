@@ -51,7 +51,8 @@ func (m *ModelObj) SetRotate(axis glm.Vec3, angle float32) {
 }
 
 func (m *ModelObj) Release() {
-	// for t := range m.textures {
-	// 	// TODO: release texture
-	// }
+	for _, t := range m.textures {
+		t.Release()
+	}
+	m.textures = nil
 }
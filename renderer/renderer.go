@@ -47,7 +47,10 @@ func TerminateAll() {
 	glfw.Terminate()
 }
 
-// Init initialize the renderer: setup OpenGL Context, compile built-in shaders.
+// Init initialize the renderer: setup OpenGL Context.
+//
+// The GL resources are not shared between windows, the built-in shaders
+// are compiled per window by shader.LoadBuiltin after the window created.
 func (r *RendererObj) Init(initParam interface{}) error {
 	if r == nil {
 		return fmt.Errorf("Init: %w", utils.ErrInvalidPointer)
@@ -107,6 +110,7 @@ func (r *RendererObj) AppendWindow(win ap.Window) error {
 		return fmt.Errorf("AppendWindow: %w", utils.ErrInvalidParameter)
 	}
 
+	win.SetViewDistance(float32(r.viewDistance))
 	r.windows = append(r.windows, win)
 	return nil
 }
@@ -123,11 +127,26 @@ func (r *RendererObj) GetViewDistance() int32 {
 	return r.viewDistance
 }
 
+// SetViewDistance sets the view distance of the renderer and the far
+// plane of the cameras of all windows.
 func (r *RendererObj) SetViewDistance(d int32) {
 	if r == nil {
 		return
 	}
 	r.viewDistance = d
+	for _, win := range r.windows {
+		win.SetViewDistance(float32(d))
+	}
+}
+
+// ApplyViewDistance sets the far plane of the camera to the view distance,
+// the cameras of the windows are applied automatically, it is used for the
+// cameras not set to any window.
+func (r *RendererObj) ApplyViewDistance(c ap.Camera) {
+	if c == nil {
+		return
+	}
+	c.SetFar(float32(r.viewDistance))
 }
 
 // Render renders all windows in renderer by calling Window.Flush() method.
@@ -0,0 +1,145 @@
+package shader
+
+import (
+	"embed"
+	"fmt"
+	"path"
+
+	"github.com/STARRY-S/aperture/utils"
+)
+
+//go:embed builtin
+var builtinFS embed.FS
+
+// Built-in shader programs, load them by LoadBuiltin or NewBuiltinVariantObj.
+//
+// All built-in programs use GLSL 330 and the vertex attribute locations:
+// 0 (vec3 aPos), 1 (vec3 aNormal), 2 (vec2 aTexCoords);
+// the transform uniforms are mat4 "model", "view" and "projection".
+// Samplers use the texture unit set by Set(name, int) or texture unit 0.
+const (
+	// BuiltinUnlitColor draws the mesh with a solid color.
+	//
+	// Attributes: aPos.
+	// Uniforms: model, view, projection, vec4 color.
+	BuiltinUnlitColor = "unlit_color"
+
+	// BuiltinUnlitTextured draws the mesh with a texture tinted by color.
+	//
+	// Attributes: aPos, aTexCoords.
+	// Uniforms: model, view, projection, sampler2D texture_diffuse,
+	// vec4 color.
+	BuiltinUnlitTextured = "unlit_textured"
+
+	// BuiltinBlinnPhong lights the mesh by one directional light with the
+	// Blinn-Phong model.
+	//
+	// Attributes: aPos, aNormal, aTexCoords.
+	// Uniforms: model, view, projection, sampler2D texture_diffuse,
+	// sampler2D texture_specular, float shininess, vec3 viewPos,
+	// vec3 lightDirection (from light to scene), vec3 lightColor,
+	// vec3 ambientColor.
+	BuiltinBlinnPhong = "blinn_phong"
+
+	// BuiltinPBR lights the mesh by up to 4 point lights with the
+	// metallic-roughness PBR model (Cook-Torrance GGX).
+	//
+	// Attributes: aPos, aNormal, aTexCoords.
+	// Uniforms: model, view, projection, vec4 albedo, float metallic,
+	// float roughness, float ao, vec3 viewPos, vec3 lightPositions[4],
+	// vec3 lightColors[4], int lightCount, vec3 ambientColor.
+	// Keywords (see NewBuiltinVariantObj):
+	// "ALBEDO_MAP" enables sampler2D texture_diffuse;
+	// "METALLIC_ROUGHNESS_MAP" enables sampler2D texture_metallic_roughness
+	// (roughness in G channel, metallic in B channel);
+	// "NORMAL_MAP" enables sampler2D texture_normal (tangent space,
+	// no tangent attribute required).
+	BuiltinPBR = "pbr"
+
+	// BuiltinSkybox draws a cube map behind the scene, the translation of
+	// the view matrix is removed in the shader, draw the unit cube with
+	// GL_LEQUAL depth function.
+	//
+	// Attributes: aPos.
+	// Uniforms: view, projection, samplerCube skybox.
+	BuiltinSkybox = "skybox"
+
+	// BuiltinDebugLines draws lines with per-vertex colors.
+	//
+	// Attributes: aPos, 1 (vec3 aColor).
+	// Uniforms: model, view, projection.
+	BuiltinDebugLines = "debug_lines"
+
+	// BuiltinBlit copies a texture to the whole viewport, it draws a
+	// fullscreen triangle generated from gl_VertexID, draw 3 vertices
+	// with an empty vertex array object bound.
+	//
+	// Attributes: none.
+	// Uniforms: sampler2D screenTexture.
+	BuiltinBlit = "blit"
+)
+
+var builtinNames = []string{
+	BuiltinUnlitColor,
+	BuiltinUnlitTextured,
+	BuiltinBlinnPhong,
+	BuiltinPBR,
+	BuiltinSkybox,
+	BuiltinDebugLines,
+	BuiltinBlit,
+}
+
+// builtinKeywords are the variant keywords supported by built-in programs.
+var builtinKeywords = map[string][]string{
+	BuiltinPBR: {"ALBEDO_MAP", "METALLIC_ROUGHNESS_MAP", "NORMAL_MAP"},
+}
+
+// GetBuiltinNames gets the names of all built-in shader programs.
+func GetBuiltinNames() []string {
+	return append([]string{}, builtinNames...)
+}
+
+// GetBuiltinKeywords gets the variant keywords of the built-in program.
+func GetBuiltinKeywords(name string) []string {
+	return append([]string{}, builtinKeywords[name]...)
+}
+
+// GetBuiltinSource gets the vertex, fragment and geometry shader sources
+// of the built-in program, geometry shader source is empty if not exist.
+func GetBuiltinSource(name string) (vs, fs, gs string, err error) {
+	vData, err := builtinFS.ReadFile(path.Join("builtin", name, "vertex.glsl"))
+	if err != nil {
+		return "", "", "", fmt.Errorf("GetBuiltinSource: %q: %w",
+			name, utils.ErrInvalidParameter)
+	}
+	fData, err := builtinFS.ReadFile(path.Join("builtin", name, "fragment.glsl"))
+	if err != nil {
+		return "", "", "", fmt.Errorf("GetBuiltinSource: %q: %w",
+			name, utils.ErrInvalidParameter)
+	}
+	gData, _ := builtinFS.ReadFile(path.Join("builtin", name, "geometry.glsl"))
+	return string(vData), string(fData), string(gData), nil
+}
+
+// LoadBuiltin compiles the built-in shader program in current OpenGL context.
+func LoadBuiltin(name string) (*ShaderObj, error) {
+	vs, fs, gs, err := GetBuiltinSource(name)
+	if err != nil {
+		return nil, fmt.Errorf("LoadBuiltin: %w", err)
+	}
+	s := &ShaderObj{}
+	if err := s.LoadMemory(vs, fs, gs); err != nil {
+		return nil, fmt.Errorf("LoadBuiltin: %q: %w", name, err)
+	}
+	return s, nil
+}
+
+// NewBuiltinVariantObj creates the variant manager of the built-in program
+// with its keywords.
+func NewBuiltinVariantObj(name string) (*VariantObj, error) {
+	vs, fs, gs, err := GetBuiltinSource(name)
+	if err != nil {
+		return nil, fmt.Errorf("NewBuiltinVariantObj: %w", err)
+	}
+	return NewVariantObj(vs, fs, gs, builtinKeywords[name])
+}
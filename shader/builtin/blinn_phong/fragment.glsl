@@ -0,0 +1,36 @@
+#version 330
+
+in vec3 FragPos;
+in vec3 Normal;
+in vec2 TexCoords;
+
+out vec4 FragColor;
+
+uniform sampler2D texture_diffuse;
+uniform sampler2D texture_specular;
+uniform float shininess;
+
+uniform vec3 viewPos;
+// lightDirection is the direction from the light to the scene
+uniform vec3 lightDirection;
+uniform vec3 lightColor;
+uniform vec3 ambientColor;
+
+void main()
+{
+    vec4 diffuseColor = texture(texture_diffuse, TexCoords);
+    vec3 specularColor = texture(texture_specular, TexCoords).rgb;
+
+    vec3 normal = normalize(Normal);
+    vec3 lightDir = normalize(-lightDirection);
+    vec3 viewDir = normalize(viewPos - FragPos);
+    vec3 halfwayDir = normalize(lightDir + viewDir);
+
+    float diff = max(dot(normal, lightDir), 0.0);
+    float spec = pow(max(dot(normal, halfwayDir), 0.0), max(shininess, 1.0));
+
+    vec3 result = ambientColor * diffuseColor.rgb
+        + diff * lightColor * diffuseColor.rgb
+        + spec * lightColor * specularColor;
+    FragColor = vec4(result, diffuseColor.a);
+}
@@ -0,0 +1,12 @@
+#version 330
+
+out vec2 TexCoords;
+
+// fullscreen triangle generated from gl_VertexID, draw 3 vertices
+// with an empty vertex array object bound.
+void main()
+{
+    vec2 pos = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2);
+    TexCoords = pos;
+    gl_Position = vec4(pos * 2.0 - 1.0, 0.0, 1.0);
+}
@@ -0,0 +1,10 @@
+#version 330
+
+in vec3 Color;
+
+out vec4 FragColor;
+
+void main()
+{
+    FragColor = vec4(Color, 1.0);
+}
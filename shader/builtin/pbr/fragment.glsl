@@ -0,0 +1,124 @@
+#version 330
+
+#define MAX_LIGHTS 4
+#define PI 3.14159265359
+
+in vec3 FragPos;
+in vec3 Normal;
+in vec2 TexCoords;
+
+out vec4 FragColor;
+
+// material parameters, multiplied with the texture maps if enabled
+uniform vec4 albedo;
+uniform float metallic;
+uniform float roughness;
+uniform float ao;
+
+#ifdef ALBEDO_MAP
+uniform sampler2D texture_diffuse;
+#endif
+#ifdef METALLIC_ROUGHNESS_MAP
+// glTF layout: roughness in green channel, metallic in blue channel
+uniform sampler2D texture_metallic_roughness;
+#endif
+#ifdef NORMAL_MAP
+uniform sampler2D texture_normal;
+#endif
+
+uniform vec3 viewPos;
+uniform vec3 lightPositions[MAX_LIGHTS];
+uniform vec3 lightColors[MAX_LIGHTS];
+uniform int lightCount;
+uniform vec3 ambientColor;
+
+#ifdef NORMAL_MAP
+// perturbNormal calculates the normal from normal map without tangents
+// by using the screen-space derivatives (cotangent frame).
+vec3 perturbNormal(vec3 n, vec3 p, vec2 uv)
+{
+    vec3 dp1 = dFdx(p);
+    vec3 dp2 = dFdy(p);
+    vec2 duv1 = dFdx(uv);
+    vec2 duv2 = dFdy(uv);
+
+    vec3 dp2perp = cross(dp2, n);
+    vec3 dp1perp = cross(n, dp1);
+    vec3 t = dp2perp * duv1.x + dp1perp * duv2.x;
+    vec3 b = dp2perp * duv1.y + dp1perp * duv2.y;
+    float invmax = inversesqrt(max(dot(t, t), dot(b, b)));
+    mat3 tbn = mat3(t * invmax, b * invmax, n);
+
+    vec3 mapped = texture(texture_normal, uv).xyz * 2.0 - 1.0;
+    return normalize(tbn * mapped);
+}
+#endif
+
+float distributionGGX(vec3 n, vec3 h, float r)
+{
+    float a = r * r;
+    float a2 = a * a;
+    float ndoth = max(dot(n, h), 0.0);
+    float denom = ndoth * ndoth * (a2 - 1.0) + 1.0;
+    return a2 / (PI * denom * denom);
+}
+
+float geometrySchlickGGX(float ndotv, float r)
+{
+    float k = (r + 1.0) * (r + 1.0) / 8.0;
+    return ndotv / (ndotv * (1.0 - k) + k);
+}
+
+float geometrySmith(vec3 n, vec3 v, vec3 l, float r)
+{
+    return geometrySchlickGGX(max(dot(n, v), 0.0), r)
+        * geometrySchlickGGX(max(dot(n, l), 0.0), r);
+}
+
+vec3 fresnelSchlick(float cosTheta, vec3 f0)
+{
+    return f0 + (1.0 - f0) * pow(clamp(1.0 - cosTheta, 0.0, 1.0), 5.0);
+}
+
+void main()
+{
+    vec4 baseColor = albedo;
+    float metal = metallic;
+    float rough = roughness;
+#ifdef ALBEDO_MAP
+    baseColor *= texture(texture_diffuse, TexCoords);
+#endif
+#ifdef METALLIC_ROUGHNESS_MAP
+    vec3 mr = texture(texture_metallic_roughness, TexCoords).rgb;
+    rough *= mr.g;
+    metal *= mr.b;
+#endif
+    rough = clamp(rough, 0.04, 1.0);
+
+    vec3 n = normalize(Normal);
+#ifdef NORMAL_MAP
+    n = perturbNormal(n, FragPos, TexCoords);
+#endif
+    vec3 v = normalize(viewPos - FragPos);
+    vec3 f0 = mix(vec3(0.04), baseColor.rgb, metal);
+
+    vec3 lo = vec3(0.0);
+    for (int i = 0; i < min(lightCount, MAX_LIGHTS); i++) {
+        vec3 l = normalize(lightPositions[i] - FragPos);
+        vec3 h = normalize(v + l);
+        float distance = length(lightPositions[i] - FragPos);
+        vec3 radiance = lightColors[i] / (distance * distance);
+
+        float ndf = distributionGGX(n, h, rough);
+        float g = geometrySmith(n, v, l, rough);
+        vec3 f = fresnelSchlick(max(dot(h, v), 0.0), f0);
+
+        vec3 specular = ndf * g * f
+            / (4.0 * max(dot(n, v), 0.0) * max(dot(n, l), 0.0) + 0.0001);
+        vec3 kd = (vec3(1.0) - f) * (1.0 - metal);
+        lo += (kd * baseColor.rgb / PI + specular) * radiance * max(dot(n, l), 0.0);
+    }
+
+    vec3 color = ambientColor * baseColor.rgb * ao + lo;
+    FragColor = vec4(color, baseColor.a);
+}
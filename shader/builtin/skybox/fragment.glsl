@@ -0,0 +1,12 @@
+#version 330
+
+in vec3 TexCoords;
+
+out vec4 FragColor;
+
+uniform samplerCube skybox;
+
+void main()
+{
+    FragColor = texture(skybox, TexCoords);
+}
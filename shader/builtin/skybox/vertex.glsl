@@ -0,0 +1,16 @@
+#version 330
+layout (location = 0) in vec3 aPos;
+
+out vec3 TexCoords;
+
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+    TexCoords = aPos;
+    // remove the translation of the view matrix
+    vec4 pos = projection * mat4(mat3(view)) * vec4(aPos, 1.0);
+    // set depth to 1.0 (far plane), draw with GL_LEQUAL depth function
+    gl_Position = pos.xyww;
+}
@@ -0,0 +1,10 @@
+#version 330
+
+out vec4 FragColor;
+
+uniform vec4 color;
+
+void main()
+{
+    FragColor = color;
+}
@@ -0,0 +1,13 @@
+#version 330
+
+in vec2 TexCoords;
+
+out vec4 FragColor;
+
+uniform sampler2D texture_diffuse;
+uniform vec4 color;
+
+void main()
+{
+    FragColor = texture(texture_diffuse, TexCoords) * color;
+}
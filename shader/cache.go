@@ -0,0 +1,158 @@
+package shader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/sirupsen/logrus"
+)
+
+// ProgramCache stores the linked program binaries (glGetProgramBinary) on
+// disk, so the shader programs do not need to be re-compiled on later runs.
+//
+// The cached binary is keyed by the hash of all stage sources, defines and
+// the GL vendor, renderer and version strings, the binary will be discarded
+// and the program will be re-compiled if the driver rejects it.
+type ProgramCache struct {
+	dir string
+}
+
+const (
+	programCacheExt   = ".bin"
+	programCacheMagic = "APPB"
+)
+
+// NewProgramCache creates a program binary cache stored in dir,
+// the directory will be created if not exist.
+func NewProgramCache(dir string) (*ProgramCache, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("NewProgramCache: %w", utils.ErrInvalidFilePath)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("NewProgramCache: %w", err)
+	}
+	return &ProgramCache{dir: dir}, nil
+}
+
+// GetDir gets the directory of the cache files.
+func (c *ProgramCache) GetDir() string {
+	return c.dir
+}
+
+// Clear removes all cached program binaries.
+func (c *ProgramCache) Clear() error {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*"+programCacheExt))
+	if err != nil {
+		return fmt.Errorf("Clear: %w", err)
+	}
+	for _, f := range files {
+		if err := os.Remove(f); err != nil {
+			return fmt.Errorf("Clear: %w", err)
+		}
+	}
+	return nil
+}
+
+// supported checks the current OpenGL context supports program binary.
+func (c *ProgramCache) supported() bool {
+	var num int32
+	gl.GetIntegerv(gl.NUM_PROGRAM_BINARY_FORMATS, &num)
+	return num > 0
+}
+
+// key calculates the cache key of the program,
+// this method requires an active OpenGL context.
+func (c *ProgramCache) key(defines []string, sources ...string) string {
+	h := sha256.New()
+	for _, name := range []uint32{gl.VENDOR, gl.RENDERER, gl.VERSION} {
+		h.Write([]byte(gl.GoStr(gl.GetString(name))))
+		h.Write([]byte{0})
+	}
+	for _, d := range defines {
+		h.Write([]byte(d))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte{0})
+	for _, s := range sources {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *ProgramCache) path(key string) string {
+	return filepath.Join(c.dir, key+programCacheExt)
+}
+
+// load loads the program binary from cache, it returns 0 if the binary
+// is not cached or the driver rejects the cached binary.
+func (c *ProgramCache) load(key string) uint32 {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return 0
+	}
+	header := len(programCacheMagic) + 4
+	if len(data) <= header || string(data[:len(programCacheMagic)]) != programCacheMagic {
+		logrus.Warnf("ProgramCache: invalid cache file %q, removed", c.path(key))
+		os.Remove(c.path(key))
+		return 0
+	}
+	format := binary.LittleEndian.Uint32(data[len(programCacheMagic):header])
+	bin := data[header:]
+
+	program := gl.CreateProgram()
+	gl.ProgramBinary(program, format, gl.Ptr(bin), int32(len(bin)))
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		// driver updated or binary format changed, re-compile the program
+		logrus.Debugf("ProgramCache: driver rejected cache file %q", c.path(key))
+		gl.DeleteProgram(program)
+		os.Remove(c.path(key))
+		return 0
+	}
+	return program
+}
+
+// save saves the binary of the linked program into cache.
+func (c *ProgramCache) save(key string, program uint32) error {
+	var length int32
+	gl.GetProgramiv(program, gl.PROGRAM_BINARY_LENGTH, &length)
+	if length <= 0 {
+		return fmt.Errorf("save: program binary not retrievable")
+	}
+
+	var format uint32
+	bin := make([]byte, length)
+	gl.GetProgramBinary(program, length, &length, &format, gl.Ptr(bin))
+
+	buf := bytes.Buffer{}
+	buf.WriteString(programCacheMagic)
+	binary.Write(&buf, binary.LittleEndian, format)
+	buf.Write(bin[:length])
+
+	// write to a temporary file first to avoid leaving a broken cache file
+	tmp, err := os.CreateTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("save: %w", err)
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("save: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("save: %w", err)
+	}
+	return nil
+}
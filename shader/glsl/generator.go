@@ -0,0 +1,183 @@
+package glsl
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/STARRY-S/aperture/utils"
+)
+
+// GenerateParam is used for customize the parameters of Generate.
+type GenerateParam struct {
+	// Package is the package name of the generated file.
+	Package string
+	// Type is the name of the generated wrapper struct.
+	Type string
+	// Command is the command line recorded in the generated file header.
+	Command string
+}
+
+// goTypes maps the GLSL types to the Go types supported by Shader.Set.
+var goTypes = map[string]string{
+	"bool":   "int32",
+	"int":    "int32",
+	"uint":   "uint32",
+	"float":  "float32",
+	"vec2":   "glm.Vec2",
+	"vec3":   "glm.Vec3",
+	"vec4":   "glm.Vec4",
+	"mat2":   "glm.Mat2",
+	"mat3":   "glm.Mat3",
+	"mat4":   "glm.Mat4",
+	"mat2x3": "glm.Mat2x3",
+	"mat3x4": "glm.Mat3x4",
+}
+
+// Generate generates the Go source of the typed wrapper struct of the
+// shader program. The wrapper embeds aperture.Shader and has a setter
+// method calling Shader.Set for each uniform, samplers are set by the
+// texture unit, uniform blocks are bound by the binding point and the
+// explicit vertex input locations are generated as constants.
+//
+// Uniforms of types not supported by Shader.Set are skipped and returned
+// in skipped.
+func Generate(d *Declarations, p GenerateParam) (src []byte, skipped []string, err error) {
+	if d == nil || p.Package == "" || !isIdent(p.Type) {
+		return nil, nil, fmt.Errorf("Generate: %w", utils.ErrInvalidParameter)
+	}
+
+	methods := make(map[string]string)
+	addMethod := func(name, glslName string) error {
+		if prev, ok := methods[name]; ok {
+			return fmt.Errorf("Generate: %q and %q both generate method %s",
+				prev, glslName, name)
+		}
+		methods[name] = glslName
+		return nil
+	}
+
+	body := bytes.Buffer{}
+	useFmt, useGlm, useGl := false, false, false
+
+	uniforms := append([]Uniform{}, d.Uniforms...)
+	sort.SliceStable(uniforms, func(i, j int) bool {
+		return uniforms[i].Name < uniforms[j].Name
+	})
+	for _, u := range uniforms {
+		goType, ok := goTypes[u.Type]
+		if u.IsSampler() {
+			goType, ok = "int32", true
+		}
+		if !ok {
+			skipped = append(skipped, u.Name)
+			continue
+		}
+		method := "Set" + exportName(u.Name)
+		if err := addMethod(method, u.Name); err != nil {
+			return nil, nil, err
+		}
+		useGlm = useGlm || strings.HasPrefix(goType, "glm.")
+
+		param := "v"
+		if u.IsSampler() {
+			param = "unit"
+		}
+		if u.ArraySize == 0 {
+			fmt.Fprintf(&body, "// %s sets the %s uniform %q.\n", method, u.Type, u.Name)
+			fmt.Fprintf(&body, "func (s %s) %s(%s %s) error {\n", p.Type, method, param, goType)
+			fmt.Fprintf(&body, "\treturn s.Set(%q, %s)\n}\n\n", u.Name, param)
+			continue
+		}
+		useFmt = true
+		format := strings.Replace(u.Location(0), "[0]", "[%d]", 1)
+		fmt.Fprintf(&body, "// %s sets the ith element of the %s[%d] uniform %q.\n",
+			method, u.Type, u.ArraySize, u.Name)
+		fmt.Fprintf(&body, "func (s %s) %s(i int, %s %s) error {\n", p.Type, method, param, goType)
+		fmt.Fprintf(&body, "\tif i < 0 || i >= %d {\n", u.ArraySize)
+		fmt.Fprintf(&body, "\t\treturn fmt.Errorf(\"%s: index %%d out of range [0, %d)\", i)\n\t}\n",
+			method, u.ArraySize)
+		fmt.Fprintf(&body, "\treturn s.Set(fmt.Sprintf(%q, i), %s)\n}\n\n", format, param)
+	}
+
+	for _, b := range d.Blocks {
+		method := "Bind" + exportName(b.Name)
+		if err := addMethod(method, b.Name); err != nil {
+			return nil, nil, err
+		}
+		useGl = true
+		fmt.Fprintf(&body, "// %s binds the uniform block %q to the binding point.\n", method, b.Name)
+		fmt.Fprintf(&body, "func (s %s) %s(binding uint32) error {\n", p.Type, method)
+		fmt.Fprintf(&body, "\tindex := gl.GetUniformBlockIndex(s.GetID(), gl.Str(%q))\n", b.Name+"\x00")
+		fmt.Fprintf(&body, "\tif index == gl.INVALID_INDEX {\n")
+		fmt.Fprintf(&body, "\t\treturn fmt.Errorf(\"%s: uniform block %%q not found\", %q)\n\t}\n", method, b.Name)
+		fmt.Fprintf(&body, "\tgl.UniformBlockBinding(s.GetID(), index, binding)\n\treturn nil\n}\n\n")
+		useFmt = true
+	}
+
+	consts := bytes.Buffer{}
+	for _, in := range d.Inputs {
+		if in.Location < 0 {
+			continue
+		}
+		name := p.Type + "Location" + exportName(in.Name)
+		if err := addMethod(name, in.Name); err != nil {
+			return nil, nil, err
+		}
+		fmt.Fprintf(&consts, "\t// %s is the location of %s vertex input %q.\n", name, in.Type, in.Name)
+		fmt.Fprintf(&consts, "\t%s = %d\n", name, in.Location)
+	}
+
+	out := bytes.Buffer{}
+	out.WriteString("// Code generated by glslgen. DO NOT EDIT.\n")
+	if p.Command != "" {
+		fmt.Fprintf(&out, "// %s\n", p.Command)
+	}
+	fmt.Fprintf(&out, "\npackage %s\n\nimport (\n", p.Package)
+	if useFmt {
+		out.WriteString("\t\"fmt\"\n\n")
+	}
+	out.WriteString("\t\"github.com/STARRY-S/aperture\"\n")
+	if useGlm {
+		out.WriteString("\t\"github.com/engoengine/glm\"\n")
+	}
+	if useGl {
+		out.WriteString("\t\"github.com/go-gl/gl/v3.3-core/gl\"\n")
+	}
+	out.WriteString(")\n\n")
+	if consts.Len() > 0 {
+		fmt.Fprintf(&out, "const (\n%s)\n\n", consts.String())
+	}
+	fmt.Fprintf(&out, "// %s is the typed wrapper of the shader program.\n", p.Type)
+	fmt.Fprintf(&out, "type %s struct {\n\taperture.Shader\n}\n\n", p.Type)
+	out.Write(body.Bytes())
+
+	src, err = format.Source(out.Bytes())
+	if err != nil {
+		return nil, nil, fmt.Errorf("Generate: %w", err)
+	}
+	return src, skipped, nil
+}
+
+// exportName converts the GLSL name to the exported Go name,
+// e.g. "texture_diffuse" to "TextureDiffuse", "light.position" to
+// "LightPosition", "lights[].color" to "LightsColor".
+func exportName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, c := range name {
+		if c == '_' || c == '.' || c == '[' || c == ']' {
+			upper = true
+			continue
+		}
+		if upper {
+			c = unicode.ToUpper(c)
+			upper = false
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,150 @@
+package glsl_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/STARRY-S/aperture/shader/glsl"
+	"github.com/stretchr/testify/assert"
+)
+
+const testVertexShader = `#version 330
+layout (location = 0) in vec3 aPos;
+layout (location = 2) in vec2 aTexCoords;
+in vec3 aColor; // without location
+
+out vec2 TexCoords;
+
+uniform mat4 model, view;
+uniform highp mat4 projection;
+
+layout (std140) uniform Matrices {
+    mat4 lightSpace;
+    vec4 params;
+};
+
+void main()
+{
+    TexCoords = aTexCoords;
+    gl_Position = projection * view * model * vec4(aPos, 1.0);
+}
+`
+
+const testFragmentShader = `#version 330
+#define MAX_LIGHTS 4
+
+struct Light {
+    vec3 position;
+    vec3 color;
+};
+
+in vec2 TexCoords;
+out vec4 FragColor;
+
+/* samplers */
+uniform sampler2D texture_diffuse;
+uniform samplerCube skybox;
+uniform Light lights[MAX_LIGHTS];
+uniform Light sun;
+uniform float weights[3];
+uniform mat4 view;
+uniform ivec2 unsupported;
+
+vec3 shade(Light l);
+
+vec3 shade(Light l)
+{
+    if (true) { return l.color; }
+}
+
+void main()
+{
+    FragColor = texture(texture_diffuse, TexCoords);
+}
+`
+
+func TestParse(t *testing.T) {
+	vd, err := glsl.Parse(testVertexShader, true)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, []glsl.Uniform{
+		{Name: "model", Type: "mat4"},
+		{Name: "view", Type: "mat4"},
+		{Name: "projection", Type: "mat4"},
+	}, vd.Uniforms)
+	assert.Equal(t, []glsl.Input{
+		{Name: "aPos", Type: "vec3", Location: 0},
+		{Name: "aTexCoords", Type: "vec2", Location: 2},
+		{Name: "aColor", Type: "vec3", Location: -1},
+	}, vd.Inputs)
+	if assert.Len(t, vd.Blocks, 1) {
+		assert.Equal(t, "Matrices", vd.Blocks[0].Name)
+		assert.Len(t, vd.Blocks[0].Members, 2)
+	}
+
+	fd, err := glsl.Parse(testFragmentShader, false)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Empty(t, fd.Inputs)
+	assert.Equal(t, []glsl.Uniform{
+		{Name: "texture_diffuse", Type: "sampler2D"},
+		{Name: "skybox", Type: "samplerCube"},
+	}, fd.Samplers())
+	assert.Contains(t, fd.Uniforms, glsl.Uniform{Name: "lights[].position", Type: "vec3", ArraySize: 4})
+	assert.Contains(t, fd.Uniforms, glsl.Uniform{Name: "sun.color", Type: "vec3"})
+	assert.Contains(t, fd.Uniforms, glsl.Uniform{Name: "weights", Type: "float", ArraySize: 3})
+	assert.Equal(t, "lights[2].position", glsl.Uniform{Name: "lights[].position", ArraySize: 4}.Location(2))
+
+	if err := vd.Merge(fd); err != nil {
+		t.Errorf(err.Error())
+	}
+	bad, _ := glsl.Parse("uniform vec3 view;", false)
+	if err := vd.Merge(bad); err == nil {
+		t.Errorf("merge uniform with different type should return error")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	d, err := glsl.Parse(testVertexShader, true)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	fd, err := glsl.Parse(testFragmentShader, false)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := d.Merge(fd); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	src, skipped, err := glsl.Generate(d, glsl.GenerateParam{
+		Package: "test",
+		Type:    "TestShader",
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, []string{"unsupported"}, skipped)
+	for _, s := range []string{
+		"func (s TestShader) SetModel(v glm.Mat4) error",
+		"func (s TestShader) SetTextureDiffuse(unit int32) error",
+		"func (s TestShader) SetLightsPosition(i int, v glm.Vec3) error",
+		`s.Set(fmt.Sprintf("lights[%d].position", i), v)`,
+		"func (s TestShader) SetSunColor(v glm.Vec3) error",
+		"func (s TestShader) BindMatrices(binding uint32) error",
+		"TestShaderLocationAPos",
+	} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("generated source does not contain %q", s)
+		}
+	}
+	if strings.Contains(string(src), "LocationAColor") {
+		t.Errorf("input without location should not generate constant")
+	}
+
+	if _, _, err := glsl.Generate(d, glsl.GenerateParam{Package: "test"}); err == nil {
+		t.Errorf("empty type name should return error")
+	}
+}
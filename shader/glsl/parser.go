@@ -0,0 +1,478 @@
+// Package glsl parses the declarations (uniforms, uniform blocks, vertex
+// inputs and samplers) of GLSL sources without an OpenGL context, and
+// generates the typed Go bindings of the shader program.
+package glsl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/STARRY-S/aperture/utils"
+)
+
+// Uniform is a uniform variable declared in the shader source,
+// the members of the struct uniforms are flattened as "name.member",
+// the members of the struct array uniforms are flattened as "name[].member".
+type Uniform struct {
+	Name string
+	Type string
+	// ArraySize is the size of the array (or the struct array of the member),
+	// 0 if the uniform is not an array.
+	ArraySize int
+}
+
+// Location gets the name used by glGetUniformLocation of the ith element.
+func (u Uniform) Location(i int) string {
+	if strings.Contains(u.Name, "[]") {
+		return strings.Replace(u.Name, "[]", fmt.Sprintf("[%d]", i), 1)
+	}
+	if u.ArraySize > 0 {
+		return fmt.Sprintf("%s[%d]", u.Name, i)
+	}
+	return u.Name
+}
+
+// IsSampler reports whether the uniform is an opaque sampler type.
+func (u Uniform) IsSampler() bool {
+	t := strings.TrimLeft(u.Type, "iu")
+	return strings.HasPrefix(t, "sampler")
+}
+
+// Block is a uniform block (interface block) declared in the shader source.
+type Block struct {
+	Name     string
+	Instance string
+	Members  []Uniform
+}
+
+// Input is a vertex input (attribute) declared in the vertex shader.
+type Input struct {
+	Name string
+	Type string
+	// Location is the explicit location in layout qualifier, -1 if not set.
+	Location int
+}
+
+// Declarations are the declarations parsed from the shader sources.
+type Declarations struct {
+	Uniforms []Uniform
+	Blocks   []Block
+	Inputs   []Input
+}
+
+// Samplers gets the sampler uniforms.
+func (d *Declarations) Samplers() []Uniform {
+	var samplers []Uniform
+	for _, u := range d.Uniforms {
+		if u.IsSampler() {
+			samplers = append(samplers, u)
+		}
+	}
+	return samplers
+}
+
+// Merge merges the declarations of another stage, the uniforms and blocks
+// declared in both stages must have the same type.
+func (d *Declarations) Merge(o *Declarations) error {
+	for _, u := range o.Uniforms {
+		found := false
+		for _, e := range d.Uniforms {
+			if e.Name != u.Name {
+				continue
+			}
+			if e.Type != u.Type || e.ArraySize != u.ArraySize {
+				return fmt.Errorf("Merge: uniform %q redeclared as %s: %w",
+					u.Name, u.Type, utils.ErrInvalidDataType)
+			}
+			found = true
+			break
+		}
+		if !found {
+			d.Uniforms = append(d.Uniforms, u)
+		}
+	}
+	for _, b := range o.Blocks {
+		found := false
+		for _, e := range d.Blocks {
+			if e.Name == b.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			d.Blocks = append(d.Blocks, b)
+		}
+	}
+	d.Inputs = append(d.Inputs, o.Inputs...)
+	return nil
+}
+
+// Parse parses the declarations of a shader source. The declarations in
+// all preprocessor conditional branches are parsed, the integer #define
+// values are expanded in array sizes.
+//
+// The "in" declarations are only meaningful in the vertex shader, set
+// vertex to false when parsing the sources of other stages.
+func Parse(source string, vertex bool) (*Declarations, error) {
+	p := parser{
+		defines: make(map[string]string),
+		structs: make(map[string][]Uniform),
+		vertex:  vertex,
+	}
+	p.tokens = p.tokenize(stripComments(source))
+	if err := p.parse(); err != nil {
+		return nil, fmt.Errorf("Parse: %w", err)
+	}
+	return &p.decl, nil
+}
+
+type parser struct {
+	tokens  []string
+	pos     int
+	defines map[string]string
+	structs map[string][]Uniform
+	vertex  bool
+	decl    Declarations
+}
+
+// stripComments replaces the comments with spaces, keeps the new lines.
+func stripComments(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "//"):
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			if i < len(s) {
+				b.WriteByte('\n')
+			}
+		case strings.HasPrefix(s[i:], "/*"):
+			i += 2
+			for i < len(s) && !strings.HasPrefix(s[i:], "*/") {
+				if s[i] == '\n' {
+					b.WriteByte('\n')
+				}
+				i++
+			}
+			i++
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// tokenize splits the source into tokens, the preprocessor lines are
+// handled here and not included in tokens.
+func (p *parser) tokenize(s string) []string {
+	var tokens []string
+	for _, line := range strings.Split(s, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			fields := strings.Fields(strings.TrimSpace(trimmed[1:]))
+			if len(fields) >= 3 && fields[0] == "define" {
+				p.defines[fields[1]] = strings.Join(fields[2:], " ")
+			}
+			continue
+		}
+		for i := 0; i < len(line); {
+			c := rune(line[i])
+			switch {
+			case unicode.IsSpace(c):
+				i++
+			case c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c):
+				j := i
+				for j < len(line) && (line[j] == '_' || line[j] == '.' ||
+					unicode.IsLetter(rune(line[j])) || unicode.IsDigit(rune(line[j]))) {
+					j++
+				}
+				tokens = append(tokens, line[i:j])
+				i = j
+			default:
+				tokens = append(tokens, string(c))
+				i++
+			}
+		}
+	}
+	return tokens
+}
+
+func (p *parser) parse() error {
+	for p.pos < len(p.tokens) {
+		stmt, block, err := p.statement()
+		if err != nil {
+			return err
+		}
+		if err := p.declaration(stmt, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statement reads the tokens of a top-level statement, block is the tokens
+// inside the braces of the statement (struct or interface block).
+// Function definitions are skipped and return empty statement.
+func (p *parser) statement() (stmt, block []string, err error) {
+	for p.pos < len(p.tokens) {
+		t := p.tokens[p.pos]
+		p.pos++
+		switch t {
+		case ";":
+			return stmt, block, nil
+		case "{":
+			inner, err := p.braces()
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(stmt) > 0 && stmt[len(stmt)-1] == ")" {
+				// function definition
+				return nil, nil, nil
+			}
+			block = inner
+			stmt = append(stmt, "{}")
+		default:
+			stmt = append(stmt, t)
+		}
+	}
+	if len(stmt) > 0 {
+		return nil, nil, fmt.Errorf("unexpected end of source after %q",
+			strings.Join(stmt, " "))
+	}
+	return nil, nil, nil
+}
+
+// braces reads the tokens until the matching close brace.
+func (p *parser) braces() ([]string, error) {
+	depth := 1
+	start := p.pos
+	for p.pos < len(p.tokens) {
+		switch p.tokens[p.pos] {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		}
+		p.pos++
+		if depth == 0 {
+			return p.tokens[start : p.pos-1], nil
+		}
+	}
+	return nil, fmt.Errorf("unmatched brace")
+}
+
+func (p *parser) declaration(stmt, block []string) error {
+	if len(stmt) == 0 {
+		return nil
+	}
+
+	// layout qualifier
+	location := -1
+	if stmt[0] == "layout" {
+		end := indexOf(stmt, ")")
+		if end < 0 {
+			return fmt.Errorf("invalid layout qualifier")
+		}
+		for i := 2; i+2 < end; i++ {
+			if stmt[i] == "location" && stmt[i+1] == "=" {
+				n, err := strconv.Atoi(p.expand(stmt[i+2]))
+				if err != nil {
+					return fmt.Errorf("invalid location %q", stmt[i+2])
+				}
+				location = n
+			}
+		}
+		stmt = stmt[end+1:]
+	}
+	stmt = p.skipQualifiers(stmt)
+	if len(stmt) == 0 {
+		return nil
+	}
+
+	switch stmt[0] {
+	case "struct":
+		if len(stmt) < 3 || block == nil {
+			return fmt.Errorf("invalid struct declaration")
+		}
+		members, err := p.members(block)
+		if err != nil {
+			return fmt.Errorf("struct %s: %w", stmt[1], err)
+		}
+		p.structs[stmt[1]] = members
+		// struct declaration with variables is not a uniform
+		return nil
+	case "uniform":
+		stmt = p.skipQualifiers(stmt[1:])
+		if block != nil {
+			// uniform block: uniform Name { ... } instance;
+			if len(stmt) < 2 {
+				return fmt.Errorf("invalid uniform block")
+			}
+			members, err := p.members(block)
+			if err != nil {
+				return fmt.Errorf("uniform block %s: %w", stmt[0], err)
+			}
+			b := Block{Name: stmt[0], Members: members}
+			if len(stmt) > 2 {
+				b.Instance = stmt[2]
+			}
+			p.decl.Blocks = append(p.decl.Blocks, b)
+			return nil
+		}
+		vars, err := p.variables(stmt)
+		if err != nil {
+			return fmt.Errorf("uniform: %w", err)
+		}
+		p.decl.Uniforms = append(p.decl.Uniforms, vars...)
+	case "in", "attribute":
+		if !p.vertex || block != nil {
+			return nil
+		}
+		vars, err := p.variables(p.skipQualifiers(stmt[1:]))
+		if err != nil {
+			return fmt.Errorf("input: %w", err)
+		}
+		for i, v := range vars {
+			input := Input{Name: v.Name, Type: v.Type, Location: -1}
+			if location >= 0 {
+				input.Location = location + i
+			}
+			p.decl.Inputs = append(p.decl.Inputs, input)
+		}
+	}
+	return nil
+}
+
+// skipQualifiers skips the precision, interpolation and memory qualifiers.
+func (p *parser) skipQualifiers(stmt []string) []string {
+	for len(stmt) > 0 {
+		switch stmt[0] {
+		case "highp", "mediump", "lowp", "flat", "smooth", "noperspective",
+			"centroid", "sample", "invariant", "precise", "const",
+			"readonly", "writeonly", "coherent", "volatile", "restrict":
+			stmt = stmt[1:]
+		default:
+			return stmt
+		}
+	}
+	return stmt
+}
+
+// members parses the member declarations of struct or uniform block.
+func (p *parser) members(tokens []string) ([]Uniform, error) {
+	var members []Uniform
+	var stmt []string
+	for _, t := range tokens {
+		if t != ";" {
+			stmt = append(stmt, t)
+			continue
+		}
+		if len(stmt) > 0 && stmt[0] == "layout" {
+			if end := indexOf(stmt, ")"); end >= 0 {
+				stmt = stmt[end+1:]
+			}
+		}
+		vars, err := p.variables(p.skipQualifiers(stmt))
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, vars...)
+		stmt = nil
+	}
+	return members, nil
+}
+
+// variables parses "type name[size], name2;" declarations, the struct
+// variables are flattened to their members.
+func (p *parser) variables(stmt []string) ([]Uniform, error) {
+	if len(stmt) < 2 {
+		return nil, fmt.Errorf("invalid declaration %q", strings.Join(stmt, " "))
+	}
+	typ := stmt[0]
+	var vars []Uniform
+	for i := 1; i < len(stmt); i++ {
+		name := stmt[i]
+		if !isIdent(name) {
+			return nil, fmt.Errorf("invalid identifier %q", name)
+		}
+		size := 0
+		if i+1 < len(stmt) && stmt[i+1] == "[" {
+			if i+3 >= len(stmt) || stmt[i+3] != "]" {
+				return nil, fmt.Errorf("invalid array size of %q", name)
+			}
+			n, err := strconv.Atoi(p.expand(stmt[i+2]))
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid array size %q of %q", stmt[i+2], name)
+			}
+			size = n
+			i += 3
+		}
+		// skip the initializer
+		if i+1 < len(stmt) && stmt[i+1] == "=" {
+			for i+1 < len(stmt) && stmt[i+1] != "," {
+				i++
+			}
+		}
+		if i+1 < len(stmt) && stmt[i+1] == "," {
+			i++
+		}
+
+		members, ok := p.structs[typ]
+		if !ok {
+			vars = append(vars, Uniform{Name: name, Type: typ, ArraySize: size})
+			continue
+		}
+		for _, m := range members {
+			if size == 0 {
+				m.Name = name + "." + m.Name
+				vars = append(vars, m)
+				continue
+			}
+			if m.ArraySize > 0 {
+				return nil, fmt.Errorf("nested array %s[].%s not supported",
+					name, m.Name)
+			}
+			m.Name = name + "[]." + m.Name
+			m.ArraySize = size
+			vars = append(vars, m)
+		}
+	}
+	return vars, nil
+}
+
+// expand expands the #define value of the token recursively.
+func (p *parser) expand(token string) string {
+	for i := 0; i < 16; i++ {
+		v, ok := p.defines[token]
+		if !ok {
+			break
+		}
+		token = v
+	}
+	return token
+}
+
+func isIdent(s string) bool {
+	if s == "" || unicode.IsDigit(rune(s[0])) || strings.Contains(s, ".") {
+		return false
+	}
+	for _, c := range s {
+		if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func indexOf(tokens []string, s string) int {
+	for i, t := range tokens {
+		if t == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/STARRY-S/aperture/utils"
 	"github.com/engoengine/glm"
 	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/sirupsen/logrus"
 )
 
 type ShaderObj struct {
@@ -15,6 +16,11 @@ type ShaderObj struct {
 	fragment string
 	geometry string
 	id       uint32
+
+	// defines are the preprocessor macros injected into every stage
+	defines []string
+	// cache is the optional program binary cache
+	cache *ProgramCache
 }
 
 // Load function loads shader program from file, the geometry shader can be empty
@@ -50,7 +56,7 @@ func (s *ShaderObj) Load(vs, fs, gs string) error {
 		}
 	}
 
-	shaderID, err := newProgram(string(vData), string(fData), string(gData))
+	shaderID, err := s.buildProgram(string(vData), string(fData), string(gData))
 	if err != nil {
 		return err
 	}
@@ -64,7 +70,10 @@ func (s *ShaderObj) Load(vs, fs, gs string) error {
 
 // LoadString function loads shader program from memory, geometry shader can be empty
 func (s *ShaderObj) LoadMemory(vs, fs, gs string) error {
-	shaderID, err := newProgram(vs, fs, gs)
+	if s == nil {
+		return utils.ErrInvalidPointer
+	}
+	shaderID, err := s.buildProgram(vs, fs, gs)
 	if err != nil {
 		return err
 	}
@@ -133,7 +142,91 @@ func (s *ShaderObj) GetID() uint32 {
 	return s.id
 }
 
-func newProgram(vertexShaderSource, fragmentShaderSource, geometryShaderSource string) (uint32, error) {
+// Release deletes the shader program.
+func (s *ShaderObj) Release() {
+	if s.id == 0 {
+		return
+	}
+	gl.DeleteProgram(s.id)
+	s.id = 0
+}
+
+// SetDefines sets the preprocessor macros injected into every stage after
+// the #version directive, each define is in "NAME" or "NAME VALUE" format.
+// Call this method before Load or LoadMemory.
+func (s *ShaderObj) SetDefines(defines []string) {
+	s.defines = defines
+}
+
+func (s *ShaderObj) GetDefines() []string {
+	return s.defines
+}
+
+// SetProgramCache sets the program binary cache used when loading the
+// shader program, set to nil to disable the cache.
+func (s *ShaderObj) SetProgramCache(c *ProgramCache) {
+	s.cache = c
+}
+
+func (s *ShaderObj) GetProgramCache() *ProgramCache {
+	return s.cache
+}
+
+// buildProgram loads the program from cache if available,
+// otherwise compiles the program and saves it into cache.
+func (s *ShaderObj) buildProgram(vs, fs, gs string) (uint32, error) {
+	if s.cache == nil || !s.cache.supported() {
+		return newProgram(
+			injectDefines(vs, s.defines),
+			injectDefines(fs, s.defines),
+			injectDefines(gs, s.defines),
+			false)
+	}
+
+	key := s.cache.key(s.defines, vs, fs, gs)
+	if program := s.cache.load(key); program != 0 {
+		return program, nil
+	}
+	program, err := newProgram(
+		injectDefines(vs, s.defines),
+		injectDefines(fs, s.defines),
+		injectDefines(gs, s.defines),
+		true)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.cache.save(key, program); err != nil {
+		logrus.Warnf("buildProgram: failed to cache program: %v", err)
+	}
+	return program, nil
+}
+
+// injectDefines inserts the #define lines after the #version directive.
+func injectDefines(source string, defines []string) string {
+	if source == "" || len(defines) == 0 {
+		return source
+	}
+	var b strings.Builder
+	for _, d := range defines {
+		b.WriteString("#define " + d + "\n")
+	}
+
+	// #version must be the first directive of the shader source
+	pos := strings.Index(source, "#version")
+	if pos < 0 {
+		return b.String() + source
+	}
+	end := strings.IndexByte(source[pos:], '\n')
+	if end < 0 {
+		return source + "\n" + b.String()
+	}
+	end += pos + 1
+	return source[:end] + b.String() + source[end:]
+}
+
+// newProgram compiles and links the shader program, set retrievable to true
+// if the program binary will be retrieved by glGetProgramBinary.
+func newProgram(vertexShaderSource, fragmentShaderSource, geometryShaderSource string, retrievable bool) (uint32, error) {
 	var vertexShader, fragmentShader, geometryShader uint32
 	var err error
 
@@ -156,6 +249,9 @@ func newProgram(vertexShaderSource, fragmentShaderSource, geometryShaderSource s
 
 	program := gl.CreateProgram()
 
+	if retrievable {
+		gl.ProgramParameteri(program, gl.PROGRAM_BINARY_RETRIEVABLE_HINT, gl.TRUE)
+	}
 	gl.AttachShader(program, vertexShader)
 	gl.AttachShader(program, fragmentShader)
 	if geometryShader != 0 {
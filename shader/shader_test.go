@@ -1,6 +1,8 @@
 package shader_test
 
 import (
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,6 +28,42 @@ func TestInterface(t *testing.T) {
 	}
 }
 
+func TestVariantKey(t *testing.T) {
+	v, err := shader.NewVariantObj("vs", "fs", "", []string{"FOG", "NORMAL_MAP", "SKINNING"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	key, err := v.Key("SKINNING", "FOG", "SKINNING")
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if key != "FOG+SKINNING" {
+		t.Errorf("key %q should be FOG+SKINNING", key)
+	}
+	if _, err := v.Get("UNKNOWN"); err == nil {
+		t.Errorf("undeclared keyword should return error")
+	}
+	if _, err := shader.NewVariantObj("vs", "fs", "", []string{"A B"}); err == nil {
+		t.Errorf("invalid keyword should return error")
+	}
+}
+
+func TestBuiltinSource(t *testing.T) {
+	for _, name := range shader.GetBuiltinNames() {
+		vs, fs, _, err := shader.GetBuiltinSource(name)
+		if err != nil {
+			t.Errorf(err.Error())
+			continue
+		}
+		if !strings.HasPrefix(vs, "#version") || !strings.HasPrefix(fs, "#version") {
+			t.Errorf("built-in shader %q should start with #version", name)
+		}
+	}
+	if _, _, _, err := shader.GetBuiltinSource("unknown"); err == nil {
+		t.Errorf("unknown built-in shader should return error")
+	}
+}
+
 func TestLoadAndSet(t *testing.T) {
 	renderer.InitAll()
 
@@ -78,3 +116,155 @@ func TestLoadAndSet(t *testing.T) {
 
 	renderer.TerminateAll()
 }
+
+func TestProgramCache(t *testing.T) {
+	renderer.InitAll()
+
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	w, err := window.NewWindowObj(&window.WindowInitParam{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	r.AppendWindow(w)
+
+	cache, err := shader.NewProgramCache(t.TempDir())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// first load compiles the program and saves the binary,
+	// second load loads the program from the cached binary.
+	for i := 0; i < 2; i++ {
+		s := shader.ShaderObj{}
+		s.SetProgramCache(cache)
+		err = s.Load(TestVertexShader, TestFragmentShader, TestGeometryShader)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if s.GetID() == 0 {
+			t.Fatalf("shader ID is 0, test failed")
+		}
+		if err := s.Set("view", glm.Ident4()); err != nil {
+			t.Errorf(err.Error())
+		}
+	}
+	if err := cache.Clear(); err != nil {
+		t.Errorf(err.Error())
+	}
+
+	r.Release()
+	renderer.TerminateAll()
+}
+
+func TestVariant(t *testing.T) {
+	renderer.InitAll()
+
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	w, err := window.NewWindowObj(&window.WindowInitParam{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	r.AppendWindow(w)
+
+	vs, _ := os.ReadFile(TestVertexShader)
+	fs, _ := os.ReadFile(TestFragmentShader)
+	v, err := shader.NewVariantObj(string(vs), string(fs), "", []string{"FOG", "SKINNING"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	s1, err := v.Get("FOG", "SKINNING")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	s2, err := v.Get("SKINNING", "FOG")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if s1 != s2 || s1.GetID() == 0 {
+		t.Errorf("variant should be cached")
+	}
+	if _, err := v.Get(); err != nil {
+		t.Errorf(err.Error())
+	}
+	if v.GetVariantNum() != 2 {
+		t.Errorf("variant num %v should be 2", v.GetVariantNum())
+	}
+
+	// changing the defines recompiles the variants in place
+	id := s1.GetID()
+	if err := v.SetDefines([]string{"MAX_LIGHTS 4"}); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if v.GetVariantNum() != 2 {
+		t.Errorf("variant num %v should be 2", v.GetVariantNum())
+	}
+	if s1.GetID() == 0 || s1.GetID() == id {
+		t.Errorf("variant should be recompiled with the new defines")
+	}
+	if d := strings.Join(s1.GetDefines(), ","); d != "MAX_LIGHTS 4,FOG,SKINNING" {
+		t.Errorf("variant defines %q mismatch", d)
+	}
+	s3, err := v.Get("FOG", "SKINNING")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if s3 != s1 {
+		t.Errorf("variant should keep the same shader after SetDefines")
+	}
+	v.Release()
+
+	r.Release()
+	renderer.TerminateAll()
+}
+
+func TestLoadBuiltin(t *testing.T) {
+	renderer.InitAll()
+
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	w, err := window.NewWindowObj(&window.WindowInitParam{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	r.AppendWindow(w)
+
+	for _, name := range shader.GetBuiltinNames() {
+		s, err := shader.LoadBuiltin(name)
+		if err != nil {
+			t.Errorf(err.Error())
+			continue
+		}
+		s.Release()
+	}
+	v, err := shader.NewBuiltinVariantObj(shader.BuiltinPBR)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if _, err := v.Get(shader.GetBuiltinKeywords(shader.BuiltinPBR)...); err != nil {
+		t.Errorf(err.Error())
+	}
+	v.Release()
+
+	r.Release()
+	renderer.TerminateAll()
+}
@@ -0,0 +1,169 @@
+package shader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/STARRY-S/aperture/utils"
+)
+
+// VariantObj manages the variants (permutations) of a shader program,
+// the variants share the same sources and differ by the enabled keywords,
+// each enabled keyword is injected into the sources as a #define.
+//
+// The variant of a keyword combination is compiled on demand when it is
+// requested for the first time and is cached by the combination key.
+type VariantObj struct {
+	vertex   string
+	fragment string
+	geometry string
+
+	// keywords are all the keywords declared by the shader sources
+	keywords map[string]bool
+	// defines are the preprocessor macros shared by all variants
+	defines  []string
+	variants map[string]*ShaderObj
+	cache    *ProgramCache
+}
+
+// NewVariantObj creates a variant manager from the shader sources in memory,
+// keywords are all the feature keywords supported by the sources,
+// the geometry shader can be empty.
+func NewVariantObj(vs, fs, gs string, keywords []string) (*VariantObj, error) {
+	if vs == "" || fs == "" {
+		return nil, fmt.Errorf("NewVariantObj: %w", utils.ErrInvalidParameter)
+	}
+	v := &VariantObj{
+		vertex:   vs,
+		fragment: fs,
+		geometry: gs,
+		keywords: make(map[string]bool),
+		variants: make(map[string]*ShaderObj),
+	}
+	for _, k := range keywords {
+		if k == "" || strings.ContainsAny(k, " \t\n") {
+			return nil, fmt.Errorf("NewVariantObj: invalid keyword %q: %w",
+				k, utils.ErrInvalidParameter)
+		}
+		v.keywords[k] = true
+	}
+	return v, nil
+}
+
+// Get gets the shader variant with the features enabled, the variant will be
+// compiled if it is not compiled yet. The order of features does not matter.
+func (v *VariantObj) Get(features ...string) (*ShaderObj, error) {
+	key, err := v.Key(features...)
+	if err != nil {
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+	if s, ok := v.variants[key]; ok {
+		return s, nil
+	}
+
+	s, err := v.compile(key)
+	if err != nil {
+		return nil, fmt.Errorf("Get: variant [%s]: %w", key, err)
+	}
+	v.variants[key] = s
+	return s, nil
+}
+
+// Key gets the cache key of the feature set, the key is the sorted and
+// de-duplicated features joined by "+".
+func (v *VariantObj) Key(features ...string) (string, error) {
+	set := make(map[string]bool, len(features))
+	for _, f := range features {
+		if !v.keywords[f] {
+			return "", fmt.Errorf("Key: undeclared keyword %q: %w",
+				f, utils.ErrInvalidParameter)
+		}
+		set[f] = true
+	}
+	keys := make([]string, 0, len(set))
+	for f := range set {
+		keys = append(keys, f)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "+"), nil
+}
+
+// GetKeywords gets all the declared keywords in sorted order.
+func (v *VariantObj) GetKeywords() []string {
+	keywords := make([]string, 0, len(v.keywords))
+	for k := range v.keywords {
+		keywords = append(keywords, k)
+	}
+	sort.Strings(keywords)
+	return keywords
+}
+
+// GetVariantNum gets the number of compiled variants.
+func (v *VariantObj) GetVariantNum() int {
+	return len(v.variants)
+}
+
+// SetDefines sets the preprocessor macros shared by all variants, the
+// compiled variants are recompiled in place if the defines changed, so the
+// shaders returned by Get stay valid. The variant failed to recompile keeps
+// the program of the old defines.
+func (v *VariantObj) SetDefines(defines []string) error {
+	if equalDefines(v.defines, defines) {
+		return nil
+	}
+	v.defines = append([]string{}, defines...)
+	for key, s := range v.variants {
+		n, err := v.compile(key)
+		if err != nil {
+			return fmt.Errorf("SetDefines: variant [%s]: %w", key, err)
+		}
+		s.Release()
+		*s = *n
+	}
+	return nil
+}
+
+// SetProgramCache sets the program binary cache used by the variants.
+func (v *VariantObj) SetProgramCache(c *ProgramCache) {
+	v.cache = c
+}
+
+// Release releases all compiled variants.
+func (v *VariantObj) Release() {
+	for _, s := range v.variants {
+		s.Release()
+	}
+	v.variants = make(map[string]*ShaderObj)
+}
+
+// compile compiles the variant of the key with the current defines.
+func (v *VariantObj) compile(key string) (*ShaderObj, error) {
+	s := &ShaderObj{}
+	s.SetDefines(v.variantDefines(key))
+	s.SetProgramCache(v.cache)
+	if err := s.LoadMemory(v.vertex, v.fragment, v.geometry); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (v *VariantObj) variantDefines(key string) []string {
+	defines := append([]string{}, v.defines...)
+	if key == "" {
+		return defines
+	}
+	return append(defines, strings.Split(key, "+")...)
+}
+
+func equalDefines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+// Package skybox has the built-in skybox pass, it draws a cube map texture
+// behind the scene with the built-in skybox shader.
+package skybox
+
+import (
+	"fmt"
+
+	ap "github.com/STARRY-S/aperture"
+	"github.com/STARRY-S/aperture/shader"
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/engoengine/glm"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// SkyboxObj draws the cube map texture as the skybox.
+type SkyboxObj struct {
+	texture ap.Texture
+	shader  *shader.ShaderObj
+
+	vao uint32
+	vbo uint32
+
+	initialized bool
+}
+
+// SkyboxInitParam is used for customize the parameters when init skybox.
+type SkyboxInitParam struct {
+	// Texture is the cube map texture, e.g. *texture.CubeTextureObj.
+	Texture ap.Texture
+}
+
+// cubeVertices is the unit cube drawn from inside.
+var cubeVertices = []float32{
+	-1, 1, -1, -1, -1, -1, 1, -1, -1, 1, -1, -1, 1, 1, -1, -1, 1, -1,
+	-1, -1, 1, -1, -1, -1, -1, 1, -1, -1, 1, -1, -1, 1, 1, -1, -1, 1,
+	1, -1, -1, 1, -1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, 1, -1, -1,
+	-1, -1, 1, -1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, 1, -1, -1, 1,
+	-1, 1, -1, 1, 1, -1, 1, 1, 1, 1, 1, 1, -1, 1, 1, -1, 1, -1,
+	-1, -1, -1, -1, -1, 1, 1, -1, -1, 1, -1, -1, -1, -1, 1, 1, -1, 1,
+}
+
+// Init compiles the built-in skybox shader and creates the cube vertex
+// buffer, it should be called after the window (OpenGL context) created.
+func (s *SkyboxObj) Init(initParam interface{}) error {
+	if s == nil {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidPointer)
+	}
+
+	if initParam == nil {
+		initParam = SkyboxInitParam{}
+	}
+	p, ok := initParam.(SkyboxInitParam)
+	if !ok {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidDataType)
+	}
+
+	if s.initialized {
+		return fmt.Errorf("Init: %w", utils.ErrReInitialize)
+	}
+
+	program, err := shader.LoadBuiltin(shader.BuiltinSkybox)
+	if err != nil {
+		return fmt.Errorf("Init: %w", err)
+	}
+	s.shader = program
+	s.texture = p.Texture
+
+	gl.GenVertexArrays(1, &s.vao)
+	gl.GenBuffers(1, &s.vbo)
+	gl.BindVertexArray(s.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, s.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(cubeVertices)*4,
+		gl.Ptr(cubeVertices), gl.STATIC_DRAW)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointerWithOffset(0, 3, gl.FLOAT, false, 3*4, 0)
+	gl.BindVertexArray(0)
+
+	// sample across the cube faces without seams
+	gl.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+
+	s.initialized = true
+	return nil
+}
+
+// Draw draws the skybox with the view matrix of the camera and the
+// projection matrix, the translation of the view matrix is removed so the
+// skybox always surrounds the camera. Draw it after the opaque objects of
+// the scene, the skybox is drawn at the far plane with GL_LEQUAL depth test
+// and does not write the depth buffer.
+func (s *SkyboxObj) Draw(cam ap.Camera, projection glm.Mat4) error {
+	if !s.initialized {
+		return fmt.Errorf("Draw: skybox not initialized: %w", utils.ErrInvalidPointer)
+	}
+	if cam == nil || s.texture == nil || s.texture.GetID() == 0 {
+		return fmt.Errorf("Draw: %w", utils.ErrInvalidParameter)
+	}
+
+	view := cam.GetViewMatrix()
+	view[12], view[13], view[14] = 0, 0, 0
+
+	gl.UseProgram(s.shader.GetID())
+	if err := s.shader.Set("view", view); err != nil {
+		return fmt.Errorf("Draw: %w", err)
+	}
+	if err := s.shader.Set("projection", projection); err != nil {
+		return fmt.Errorf("Draw: %w", err)
+	}
+	if err := s.shader.Set("skybox", int32(0)); err != nil {
+		return fmt.Errorf("Draw: %w", err)
+	}
+
+	// the depth state is changed after the uniforms are set, so the errors
+	// above do not leave the depth writes disabled
+	var depthFunc int32
+	var depthMask bool
+	gl.GetIntegerv(gl.DEPTH_FUNC, &depthFunc)
+	gl.GetBooleanv(gl.DEPTH_WRITEMASK, &depthMask)
+	gl.DepthFunc(gl.LEQUAL)
+	gl.DepthMask(false)
+
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, s.texture.GetID())
+	gl.BindVertexArray(s.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(cubeVertices)/3))
+	gl.BindVertexArray(0)
+
+	gl.DepthMask(depthMask)
+	gl.DepthFunc(uint32(depthFunc))
+	return nil
+}
+
+// SetTexture sets the cube map texture of the skybox.
+func (s *SkyboxObj) SetTexture(t ap.Texture) {
+	s.texture = t
+}
+
+func (s *SkyboxObj) GetTexture() ap.Texture {
+	return s.texture
+}
+
+func (s *SkyboxObj) GetShader() *shader.ShaderObj {
+	return s.shader
+}
+
+// Release deletes the shader program and the vertex buffer of the skybox,
+// the cube map texture is not deleted.
+func (s *SkyboxObj) Release() {
+	if !s.initialized {
+		return
+	}
+	s.shader.Release()
+	gl.DeleteVertexArrays(1, &s.vao)
+	gl.DeleteBuffers(1, &s.vbo)
+	s.vao, s.vbo = 0, 0
+	s.initialized = false
+}
+
+func NewSkyboxObj(p *SkyboxInitParam) (*SkyboxObj, error) {
+	s := SkyboxObj{}
+	if p == nil {
+		p = &SkyboxInitParam{}
+	}
+	if err := s.Init(*p); err != nil {
+		return nil, fmt.Errorf("NewSkyboxObj: %w", err)
+	}
+	return &s, nil
+}
@@ -0,0 +1,50 @@
+package skybox_test
+
+import (
+	"testing"
+
+	"github.com/STARRY-S/aperture/camera"
+	"github.com/STARRY-S/aperture/renderer"
+	"github.com/STARRY-S/aperture/skybox"
+	"github.com/STARRY-S/aperture/texture"
+	"github.com/STARRY-S/aperture/window"
+	"github.com/engoengine/glm"
+)
+
+func TestDraw(t *testing.T) {
+	s := skybox.SkyboxObj{}
+	if err := s.Draw(nil, glm.Ident4()); err == nil {
+		t.Errorf("draw uninitialized skybox should return error")
+	}
+
+	renderer.InitAll()
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf("failed to init renderer")
+	}
+	win, err := window.NewWindowObj(&window.WindowInitParam{})
+	r.AppendWindow(win)
+
+	cube, _ := texture.NewCubeTextureObj(nil)
+	data := make([]byte, 8*6*4)
+	if err := cube.LoadMemory(8, 6, &data); err != nil {
+		t.Fatalf(err.Error())
+	}
+	sky, err := skybox.NewSkyboxObj(&skybox.SkyboxInitParam{Texture: cube})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	cam, _ := camera.NewCameraObj()
+	projection := glm.Perspective(glm.DegToRad(45), 1, 0.1, 100)
+	if err := sky.Draw(cam, projection); err != nil {
+		t.Errorf(err.Error())
+	}
+	sky.Release()
+
+	r.Release()
+	renderer.TerminateAll()
+}
@@ -0,0 +1,375 @@
+// Package atlas packs many small images into one or more texture atlas
+// pages, the region of each image is looked up by name.
+package atlas
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"sort"
+
+	"github.com/STARRY-S/aperture/texture"
+	"github.com/STARRY-S/aperture/utils"
+)
+
+// AtlasObj packs the images into the atlas pages by the MaxRects
+// algorithm and uploads the pages as textures.
+type AtlasObj struct {
+	pageWidth  int
+	pageHeight int
+	padding    int
+	extrude    int
+	param      *texture.TextureParam
+
+	// names keeps the adding order of the images
+	names  []string
+	images map[string]image.Image
+
+	table    Table
+	pages    []*image.RGBA
+	textures []*texture.TextureObj
+}
+
+// AtlasInitParam is used for customize the parameters when init atlas.
+type AtlasInitParam struct {
+	// PageWidth and PageHeight are the size of each page, default is 1024.
+	PageWidth  int
+	PageHeight int
+	// Padding is the transparent pixels between the images.
+	Padding int
+	// Extrude repeats the edge pixels of each image around it to avoid the
+	// bleeding of the linear filtering and mipmaps.
+	Extrude int
+	// TextureParam is the parameters of the page textures.
+	TextureParam *texture.TextureParam
+}
+
+// Region is the region of one image in the atlas.
+type Region struct {
+	// Page is the index of the page of the image.
+	Page int `json:"page"`
+	// X, Y, Width and Height are the pixel rectangle of the image in the
+	// page without extrusion, the origin is the top-left corner.
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// U0, V0, U1 and V1 are the texture coordinates of the top-left and
+	// bottom-right corners, the pages are uploaded top row first, so V0 is
+	// less than V1.
+	U0 float32 `json:"u0"`
+	V0 float32 `json:"v0"`
+	U1 float32 `json:"u1"`
+	V1 float32 `json:"v1"`
+}
+
+// Table is the name to region lookup table of the atlas, it can be saved
+// to JSON and loaded to look up the regions of the pre-packed atlas.
+type Table struct {
+	PageWidth  int               `json:"page_width"`
+	PageHeight int               `json:"page_height"`
+	Pages      int               `json:"pages"`
+	Regions    map[string]Region `json:"regions"`
+}
+
+const (
+	defaultPageSize = 1024
+)
+
+func (a *AtlasObj) Init(initParam interface{}) error {
+	if a == nil {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidPointer)
+	}
+
+	if initParam == nil {
+		initParam = AtlasInitParam{}
+	}
+	p, ok := initParam.(AtlasInitParam)
+	if !ok {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidDataType)
+	}
+
+	if p.PageWidth <= 0 {
+		p.PageWidth = defaultPageSize
+	}
+	if p.PageHeight <= 0 {
+		p.PageHeight = defaultPageSize
+	}
+	if p.Padding < 0 || p.Extrude < 0 {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidParameter)
+	}
+	a.pageWidth, a.pageHeight = p.PageWidth, p.PageHeight
+	a.padding, a.extrude = p.Padding, p.Extrude
+	a.param = p.TextureParam
+	a.names = nil
+	a.images = make(map[string]image.Image)
+	a.table = Table{}
+	a.pages = nil
+	a.textures = nil
+	return nil
+}
+
+// Add adds the image with the name, the image is packed by Pack.
+func (a *AtlasObj) Add(name string, img image.Image) error {
+	if img == nil {
+		return fmt.Errorf("Add: %w", utils.ErrInvalidPointer)
+	}
+	if a.images == nil {
+		a.images = make(map[string]image.Image)
+	}
+	if _, ok := a.images[name]; ok {
+		return fmt.Errorf("Add: image %q already added: %w",
+			name, utils.ErrInvalidParameter)
+	}
+	b := img.Bounds()
+	if b.Dx()+2*a.extrude > a.pageWidth || b.Dy()+2*a.extrude > a.pageHeight {
+		return fmt.Errorf("Add: image %q of %dx%d exceeds the page size: %w",
+			name, b.Dx(), b.Dy(), utils.ErrPositionExceed)
+	}
+	a.names = append(a.names, name)
+	a.images[name] = img
+	return nil
+}
+
+// AddFile decodes the image file (JPEG or PNG) and adds it with the name.
+func (a *AtlasObj) AddFile(name string, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("AddFile: %w", err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return fmt.Errorf("AddFile: failed to decode %q: %w", file, err)
+	}
+	if err := a.Add(name, img); err != nil {
+		return fmt.Errorf("AddFile: %w", err)
+	}
+	return nil
+}
+
+// Pack packs all added images into the pages on the CPU, the pages are
+// uploaded as textures by Upload. The larger images are placed first.
+// The textures uploaded from the previous pages are released.
+func (a *AtlasObj) Pack() error {
+	if len(a.names) == 0 {
+		return fmt.Errorf("Pack: no image added: %w", utils.ErrInvalidParameter)
+	}
+	names := make([]string, len(a.names))
+	copy(names, a.names)
+	sort.SliceStable(names, func(i, j int) bool {
+		bi, bj := a.images[names[i]].Bounds(), a.images[names[j]].Bounds()
+		mi, mj := max(bi.Dx(), bi.Dy()), max(bj.Dx(), bj.Dy())
+		if mi != mj {
+			return mi > mj
+		}
+		return bi.Dx()*bi.Dy() > bj.Dx()*bj.Dy()
+	})
+
+	// the padding is added to the right and bottom of each cell, the bins
+	// are enlarged by the padding so the cells can touch the page edges
+	var bins []*maxRects
+	a.Release()
+	a.pages = nil
+	a.table = Table{
+		PageWidth:  a.pageWidth,
+		PageHeight: a.pageHeight,
+		Regions:    make(map[string]Region),
+	}
+	for _, name := range names {
+		img := a.images[name]
+		b := img.Bounds()
+		w := b.Dx() + 2*a.extrude + a.padding
+		h := b.Dy() + 2*a.extrude + a.padding
+
+		page := -1
+		var cell image.Rectangle
+		for i, bin := range bins {
+			if r, ok := bin.insert(w, h); ok {
+				page, cell = i, r
+				break
+			}
+		}
+		if page < 0 {
+			bin := newMaxRects(a.pageWidth+a.padding, a.pageHeight+a.padding)
+			r, ok := bin.insert(w, h)
+			if !ok {
+				return fmt.Errorf("Pack: image %q exceeds the page size: %w",
+					name, utils.ErrPositionExceed)
+			}
+			bins = append(bins, bin)
+			a.pages = append(a.pages,
+				image.NewRGBA(image.Rect(0, 0, a.pageWidth, a.pageHeight)))
+			page, cell = len(bins)-1, r
+		}
+
+		x, y := cell.Min.X+a.extrude, cell.Min.Y+a.extrude
+		drawExtruded(a.pages[page], img, x, y, a.extrude)
+		a.table.Regions[name] = Region{
+			Page:   page,
+			X:      x,
+			Y:      y,
+			Width:  b.Dx(),
+			Height: b.Dy(),
+			U0:     float32(x) / float32(a.pageWidth),
+			V0:     float32(y) / float32(a.pageHeight),
+			U1:     float32(x+b.Dx()) / float32(a.pageWidth),
+			V1:     float32(y+b.Dy()) / float32(a.pageHeight),
+		}
+	}
+	a.table.Pages = len(a.pages)
+	return nil
+}
+
+// drawExtruded draws the image at (x, y) of the page and repeats the edge
+// pixels of the image by extrude pixels around it.
+func drawExtruded(page *image.RGBA, img image.Image, x, y, extrude int) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	draw.Draw(page, image.Rect(x, y, x+w, y+h), img, b.Min, draw.Src)
+	if extrude == 0 || w == 0 || h == 0 {
+		return
+	}
+	for py := y - extrude; py < y+h+extrude; py++ {
+		sy := clamp(py, y, y+h-1)
+		for px := x - extrude; px < x+w+extrude; px++ {
+			if px >= x && px < x+w && py >= y && py < y+h {
+				continue
+			}
+			page.SetRGBA(px, py, page.RGBAAt(clamp(px, x, x+w-1), sy))
+		}
+	}
+}
+
+// Upload uploads the packed pages as textures, the previously uploaded
+// textures are released, this method requires an active OpenGL context.
+func (a *AtlasObj) Upload() error {
+	if len(a.pages) == 0 {
+		return fmt.Errorf("Upload: atlas not packed: %w", utils.ErrInvalidParameter)
+	}
+	a.Release()
+	textures := make([]*texture.TextureObj, 0, len(a.pages))
+	release := func() {
+		for _, t := range textures {
+			t.Release()
+		}
+	}
+	for _, page := range a.pages {
+		t, err := texture.NewTextureObj(a.param)
+		if err != nil {
+			release()
+			return fmt.Errorf("Upload: %w", err)
+		}
+		if err := t.LoadMemory(a.pageWidth, a.pageHeight, &page.Pix); err != nil {
+			t.Release()
+			release()
+			return fmt.Errorf("Upload: %w", err)
+		}
+		textures = append(textures, t)
+	}
+	a.textures = textures
+	return nil
+}
+
+// Release deletes the uploaded page textures.
+func (a *AtlasObj) Release() {
+	for _, t := range a.textures {
+		t.Release()
+	}
+	a.textures = nil
+}
+
+// GetRegion gets the region of the image by name.
+func (a *AtlasObj) GetRegion(name string) (Region, bool) {
+	return a.table.Lookup(name)
+}
+
+// GetTable gets the lookup table of the packed atlas.
+func (a *AtlasObj) GetTable() Table {
+	return a.table
+}
+
+// GetPages gets the packed page images.
+func (a *AtlasObj) GetPages() []*image.RGBA {
+	return a.pages
+}
+
+// GetTextures gets the uploaded page textures.
+func (a *AtlasObj) GetTextures() []*texture.TextureObj {
+	return a.textures
+}
+
+// GetTexture gets the uploaded page texture of the image by name.
+func (a *AtlasObj) GetTexture(name string) *texture.TextureObj {
+	r, ok := a.table.Lookup(name)
+	if !ok || r.Page >= len(a.textures) {
+		return nil
+	}
+	return a.textures[r.Page]
+}
+
+// Lookup gets the region of the image by name.
+func (t *Table) Lookup(name string) (Region, bool) {
+	r, ok := t.Regions[name]
+	return r, ok
+}
+
+// Save saves the table to the JSON file.
+func (t *Table) Save(file string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	return nil
+}
+
+// LoadTable loads the table from the JSON file.
+func LoadTable(file string) (*Table, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("LoadTable: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("LoadTable: %w", utils.ErrEmptyFile)
+	}
+	t := Table{}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("LoadTable: %w", err)
+	}
+	return &t, nil
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func NewAtlasObj(p *AtlasInitParam) (*AtlasObj, error) {
+	a := AtlasObj{}
+	if p == nil {
+		p = &AtlasInitParam{}
+	}
+	if err := a.Init(*p); err != nil {
+		return nil, fmt.Errorf("NewAtlasObj: %w", err)
+	}
+	return &a, nil
+}
@@ -0,0 +1,127 @@
+package atlas_test
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/STARRY-S/aperture/texture/atlas"
+	"github.com/stretchr/testify/assert"
+)
+
+func newImage(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestPack(t *testing.T) {
+	a, err := atlas.NewAtlasObj(&atlas.AtlasInitParam{
+		PageWidth:  64,
+		PageHeight: 64,
+		Padding:    1,
+		Extrude:    1,
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := a.Pack(); err == nil {
+		t.Errorf("pack without images should return error")
+	}
+	if err := a.Add("large", newImage(64, 64, color.RGBA{})); err == nil {
+		t.Errorf("image exceeds page size should return error")
+	}
+
+	for i := 0; i < 40; i++ {
+		w, h := 4+i%5*2, 3+i%7
+		c := color.RGBA{uint8(i + 1), 0, 0, 255}
+		if err := a.Add(fmt.Sprintf("sprite%d", i), newImage(w, h, c)); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	if err := a.Add("sprite0", newImage(1, 1, color.RGBA{})); err == nil {
+		t.Errorf("duplicated name should return error")
+	}
+	if err := a.Pack(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	table := a.GetTable()
+	assert.Equal(t, 40, len(table.Regions))
+	assert.Equal(t, len(a.GetPages()), table.Pages)
+
+	// the extruded regions must not overlap on the same page
+	var rects []image.Rectangle
+	var pages []int
+	for i := 0; i < 40; i++ {
+		name := fmt.Sprintf("sprite%d", i)
+		r, ok := a.GetRegion(name)
+		if !ok {
+			t.Fatalf("region %q not found", name)
+		}
+		assert.Equal(t, 4+i%5*2, r.Width)
+		assert.Equal(t, 3+i%7, r.Height)
+		outer := image.Rect(r.X-1, r.Y-1, r.X+r.Width+1, r.Y+r.Height+1)
+		if !outer.In(image.Rect(0, 0, 64, 64)) {
+			t.Errorf("region %q %v is out of page", name, outer)
+		}
+		for j, o := range rects {
+			if pages[j] == r.Page && o.Overlaps(outer) {
+				t.Errorf("region %q %v overlaps %v", name, outer, o)
+			}
+		}
+		rects = append(rects, outer)
+		pages = append(pages, r.Page)
+
+		page := a.GetPages()[r.Page]
+		assert.Equal(t, uint8(i+1), page.RGBAAt(r.X, r.Y).R)
+		// extruded edge pixels
+		assert.Equal(t, uint8(i+1), page.RGBAAt(r.X-1, r.Y-1).R)
+		assert.Equal(t, uint8(i+1), page.RGBAAt(r.X+r.Width, r.Y+r.Height).R)
+		assert.InDelta(t, float32(r.X)/64, r.U0, 1e-6)
+		assert.InDelta(t, float32(r.Y+r.Height)/64, r.V1, 1e-6)
+	}
+}
+
+func TestMultiplePages(t *testing.T) {
+	a, _ := atlas.NewAtlasObj(&atlas.AtlasInitParam{
+		PageWidth:  32,
+		PageHeight: 32,
+	})
+	for i := 0; i < 5; i++ {
+		a.Add(fmt.Sprintf("%d", i), newImage(16, 32, color.RGBA{}))
+	}
+	if err := a.Pack(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, 3, len(a.GetPages()))
+	r, _ := a.GetRegion("4")
+	assert.Equal(t, 2, r.Page)
+	assert.Nil(t, a.GetTexture("4"), "texture should be nil before uploaded")
+}
+
+func TestTableJSON(t *testing.T) {
+	a, _ := atlas.NewAtlasObj(nil)
+	a.Add("icon", newImage(8, 8, color.RGBA{}))
+	if err := a.Pack(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	file := filepath.Join(t.TempDir(), "atlas.json")
+	table := a.GetTable()
+	if err := table.Save(file); err != nil {
+		t.Fatalf(err.Error())
+	}
+	loaded, err := atlas.LoadTable(file)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, table, *loaded)
+	r, ok := loaded.Lookup("icon")
+	assert.True(t, ok)
+	assert.Equal(t, 8, r.Width)
+}
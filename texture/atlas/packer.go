@@ -0,0 +1,90 @@
+package atlas
+
+import "image"
+
+// maxRects is the MaxRects bin packer of one page, it places the
+// rectangles by the best short side fit heuristic without rotation.
+type maxRects struct {
+	width  int
+	height int
+	// free is the list of maximal free rectangles, they may overlap
+	free []image.Rectangle
+}
+
+func newMaxRects(width, height int) *maxRects {
+	return &maxRects{
+		width:  width,
+		height: height,
+		free:   []image.Rectangle{image.Rect(0, 0, width, height)},
+	}
+}
+
+// insert places the w x h rectangle, it returns false if there is no
+// free space large enough.
+func (m *maxRects) insert(w, h int) (image.Rectangle, bool) {
+	best := -1
+	bestShort, bestLong := 0, 0
+	for i, f := range m.free {
+		fw, fh := f.Dx(), f.Dy()
+		if fw < w || fh < h {
+			continue
+		}
+		short, long := fw-w, fh-h
+		if short > long {
+			short, long = long, short
+		}
+		if best < 0 || short < bestShort || (short == bestShort && long < bestLong) {
+			best, bestShort, bestLong = i, short, long
+		}
+	}
+	if best < 0 {
+		return image.Rectangle{}, false
+	}
+	min := m.free[best].Min
+	placed := image.Rectangle{Min: min, Max: min.Add(image.Pt(w, h))}
+	m.place(placed)
+	return placed, true
+}
+
+// place splits the free rectangles overlapped by the placed rectangle
+// and removes the free rectangles contained by others.
+func (m *maxRects) place(r image.Rectangle) {
+	var free []image.Rectangle
+	for _, f := range m.free {
+		if !f.Overlaps(r) {
+			free = append(free, f)
+			continue
+		}
+		if r.Min.X > f.Min.X {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, r.Min.X, f.Max.Y))
+		}
+		if r.Max.X < f.Max.X {
+			free = append(free, image.Rect(r.Max.X, f.Min.Y, f.Max.X, f.Max.Y))
+		}
+		if r.Min.Y > f.Min.Y {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, f.Max.X, r.Min.Y))
+		}
+		if r.Max.Y < f.Max.Y {
+			free = append(free, image.Rect(f.Min.X, r.Max.Y, f.Max.X, f.Max.Y))
+		}
+	}
+
+	m.free = m.free[:0]
+	for i, a := range free {
+		contained := false
+		for j, b := range free {
+			if i == j || !a.In(b) {
+				continue
+			}
+			// keep the first one of the equal rectangles
+			if a.Eq(b) && i < j {
+				continue
+			}
+			contained = true
+			break
+		}
+		if !contained {
+			m.free = append(m.free, a)
+		}
+	}
+}
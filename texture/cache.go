@@ -0,0 +1,131 @@
+package texture
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/STARRY-S/aperture/utils"
+)
+
+// CacheObj is the texture cache of one OpenGL context, the textures are
+// cached by the absolute file path and the texture parameters, loading the
+// same file twice returns the shared texture with reference counting.
+//
+// Each texture returned by Load should be released by TextureObj.Release
+// once, the OpenGL texture is deleted when the last reference released.
+type CacheObj struct {
+	mu       sync.Mutex
+	entries  map[cacheKey]*cacheEntry
+	textures map[*TextureObj]*cacheEntry
+}
+
+type cacheKey struct {
+	path  string
+	param TextureParam
+}
+
+type cacheEntry struct {
+	key     cacheKey
+	texture *TextureObj
+	refs    int
+}
+
+// Load loads the texture file with the parameters (nil uses the default
+// parameters), or gets the cached texture and increases its reference
+// count. This method requires the OpenGL context of the cache.
+func (c *CacheObj) Load(file string, p *TextureParam) (*TextureObj, error) {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return nil, fmt.Errorf("Load: %w", utils.ErrInvalidFilePath)
+	}
+	var param TextureParam
+	if p != nil {
+		param = *p
+	}
+	// the parameters are normalized so the default values share the key
+	np, err := param.normalize()
+	if err != nil {
+		return nil, fmt.Errorf("Load: %w", err)
+	}
+	key := cacheKey{path: path, param: np}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.refs++
+		return e.texture, nil
+	}
+
+	t := &TextureObj{param: param}
+	if err := t.Load(file); err != nil {
+		return nil, err
+	}
+	t.cache = c
+	e := &cacheEntry{key: key, texture: t, refs: 1}
+	if c.entries == nil {
+		c.entries = make(map[cacheKey]*cacheEntry)
+		c.textures = make(map[*TextureObj]*cacheEntry)
+	}
+	c.entries[key] = e
+	c.textures[t] = e
+	return t, nil
+}
+
+// release releases one reference of the texture, the texture is deleted
+// and removed from the cache when the last reference released.
+func (c *CacheObj) release(t *TextureObj) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.textures[t]
+	if !ok {
+		return
+	}
+	e.refs--
+	if e.refs > 0 {
+		return
+	}
+	delete(c.entries, e.key)
+	delete(c.textures, t)
+	t.cache = nil
+	t.delete()
+}
+
+// GetRefCount gets the reference count of the cached texture,
+// it returns 0 if the texture is not in the cache.
+func (c *CacheObj) GetRefCount(t *TextureObj) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.textures[t]; ok {
+		return e.refs
+	}
+	return 0
+}
+
+// GetCount gets the count of the cached textures.
+func (c *CacheObj) GetCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Clear deletes all cached textures regardless of the reference count,
+// it should be called before the OpenGL context destroyed.
+func (c *CacheObj) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for t := range c.textures {
+		t.cache = nil
+		t.delete()
+	}
+	c.entries = nil
+	c.textures = nil
+}
+
+func NewCacheObj() (*CacheObj, error) {
+	c := CacheObj{
+		entries:  make(map[cacheKey]*cacheEntry),
+		textures: make(map[*TextureObj]*cacheEntry),
+	}
+	return &c, nil
+}
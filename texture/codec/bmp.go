@@ -0,0 +1,262 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+)
+
+var errBMPUnsupported = errors.New("bmp: unsupported format")
+
+const (
+	bmpFileHeaderSize = 14
+
+	bmpCompressionRGB            = 0
+	bmpCompressionBitFields      = 3
+	bmpCompressionAlphaBitFields = 6
+
+	// bmpMaxSize is the maximum width and height of the BMP image.
+	bmpMaxSize = 1 << 24
+)
+
+func init() {
+	image.RegisterFormat("bmp", "BM", DecodeBMP, DecodeBMPConfig)
+}
+
+type bmpHeader struct {
+	width       int
+	height      int
+	topDown     bool
+	bpp         int
+	compression uint32
+	dataOffset  uint32
+	// masks are the R, G, B, A bit masks of 16 and 32 bpp images
+	masks   [4]uint32
+	palette color.Palette
+}
+
+func readBMPHeader(r io.Reader) (*bmpHeader, error) {
+	var fh [bmpFileHeaderSize + 4]byte
+	if _, err := io.ReadFull(r, fh[:]); err != nil {
+		return nil, err
+	}
+	if string(fh[:2]) != "BM" {
+		return nil, errors.New("bmp: invalid format")
+	}
+	h := &bmpHeader{dataOffset: binary.LittleEndian.Uint32(fh[10:14])}
+	infoSize := binary.LittleEndian.Uint32(fh[14:18])
+	if infoSize < 12 || infoSize > 1024 {
+		return nil, errBMPUnsupported
+	}
+	info := make([]byte, infoSize)
+	copy(info, fh[14:18])
+	if _, err := io.ReadFull(r, info[4:]); err != nil {
+		return nil, err
+	}
+
+	paletteEntrySize := 4
+	numColors := 0
+	if infoSize == 12 {
+		// BITMAPCOREHEADER
+		h.width = int(binary.LittleEndian.Uint16(info[4:6]))
+		h.height = int(int16(binary.LittleEndian.Uint16(info[6:8])))
+		h.bpp = int(binary.LittleEndian.Uint16(info[10:12]))
+		paletteEntrySize = 3
+	} else {
+		if infoSize < 40 {
+			return nil, errBMPUnsupported
+		}
+		h.width = int(int32(binary.LittleEndian.Uint32(info[4:8])))
+		h.height = int(int32(binary.LittleEndian.Uint32(info[8:12])))
+		h.bpp = int(binary.LittleEndian.Uint16(info[14:16]))
+		h.compression = binary.LittleEndian.Uint32(info[16:20])
+		numColors = int(binary.LittleEndian.Uint32(info[32:36]))
+	}
+	if h.height < 0 {
+		h.height = -h.height
+		h.topDown = true
+	}
+	if h.width <= 0 || h.height <= 0 || h.width > bmpMaxSize || h.height > bmpMaxSize {
+		return nil, errors.New("bmp: invalid image size")
+	}
+
+	switch h.compression {
+	case bmpCompressionRGB:
+		switch h.bpp {
+		case 16:
+			h.masks = [4]uint32{0x7c00, 0x03e0, 0x001f, 0}
+		case 24, 32:
+			h.masks = [4]uint32{0xff0000, 0xff00, 0xff, 0}
+		}
+	case bmpCompressionBitFields, bmpCompressionAlphaBitFields:
+		if h.bpp != 16 && h.bpp != 32 {
+			return nil, errBMPUnsupported
+		}
+		n := 3
+		if h.compression == bmpCompressionAlphaBitFields || infoSize >= 56 {
+			n = 4
+		}
+		masks := info[40:]
+		if infoSize == 40 {
+			// the masks follow the info header
+			masks = make([]byte, 4*n)
+			if _, err := io.ReadFull(r, masks); err != nil {
+				return nil, err
+			}
+			infoSize += uint32(4 * n)
+		}
+		if len(masks) < 4*n {
+			return nil, errors.New("bmp: truncated header")
+		}
+		for i := 0; i < n; i++ {
+			h.masks[i] = binary.LittleEndian.Uint32(masks[4*i:])
+		}
+	default:
+		// RLE and embedded JPEG/PNG compressions
+		return nil, errBMPUnsupported
+	}
+
+	switch h.bpp {
+	case 1, 2, 4, 8:
+		if numColors == 0 || numColors > 1<<h.bpp {
+			numColors = 1 << h.bpp
+		}
+		buf := make([]byte, numColors*paletteEntrySize)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		infoSize += uint32(len(buf))
+		h.palette = make(color.Palette, numColors)
+		for i := range h.palette {
+			e := buf[i*paletteEntrySize:]
+			h.palette[i] = color.RGBA{e[2], e[1], e[0], 0xff}
+		}
+	case 16, 24, 32:
+	default:
+		return nil, errBMPUnsupported
+	}
+
+	// skip the gap between headers and pixel data
+	read := uint32(bmpFileHeaderSize) + infoSize
+	if h.dataOffset > read {
+		if _, err := io.CopyN(io.Discard, r, int64(h.dataOffset-read)); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// DecodeBMPConfig returns the color model and dimensions of a BMP image
+// without decoding the entire image.
+func DecodeBMPConfig(r io.Reader) (image.Config, error) {
+	h, err := readBMPHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	var model color.Model = color.NRGBAModel
+	if h.palette != nil {
+		model = h.palette
+	}
+	return image.Config{ColorModel: model, Width: h.width, Height: h.height}, nil
+}
+
+// DecodeBMP reads a BMP image from r and returns it as an image.Image,
+// the paletted images are decoded as *image.Paletted, others are decoded
+// as *image.NRGBA. The RLE compressed images are not supported.
+func DecodeBMP(r io.Reader) (image.Image, error) {
+	h, err := readBMPHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// read the pixel data before allocating the image, so the size in the
+	// header of a truncated file does not allocate the memory up front
+	rowSize := (h.width*h.bpp + 31) / 32 * 4
+	data := bytes.Buffer{}
+	if _, err := io.CopyN(&data, r, int64(rowSize)*int64(h.height)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("bmp: %w", err)
+	}
+	rect := image.Rect(0, 0, h.width, h.height)
+
+	var paletted *image.Paletted
+	var nrgba *image.NRGBA
+	if h.palette != nil {
+		paletted = image.NewPaletted(rect, h.palette)
+	} else {
+		nrgba = image.NewNRGBA(rect)
+	}
+
+	// alpha channel is only used if any pixel has non-zero alpha
+	hasAlpha := false
+	for i := 0; i < h.height; i++ {
+		row := data.Next(rowSize)
+		y := h.height - 1 - i
+		if h.topDown {
+			y = i
+		}
+
+		if paletted != nil {
+			mask := byte(1<<h.bpp - 1)
+			for x := 0; x < h.width; x++ {
+				bit := x * h.bpp
+				v := row[bit/8] >> (8 - h.bpp - bit%8) & mask
+				if int(v) >= len(h.palette) {
+					v = 0
+				}
+				paletted.Pix[y*paletted.Stride+x] = v
+			}
+			continue
+		}
+
+		pix := nrgba.Pix[y*nrgba.Stride : y*nrgba.Stride+h.width*4]
+		for x := 0; x < h.width; x++ {
+			var v uint32
+			switch h.bpp {
+			case 16:
+				v = uint32(binary.LittleEndian.Uint16(row[2*x:]))
+			case 24:
+				v = uint32(row[3*x]) | uint32(row[3*x+1])<<8 | uint32(row[3*x+2])<<16
+			case 32:
+				v = binary.LittleEndian.Uint32(row[4*x:])
+			}
+			pix[4*x] = maskValue(v, h.masks[0])
+			pix[4*x+1] = maskValue(v, h.masks[1])
+			pix[4*x+2] = maskValue(v, h.masks[2])
+			if h.masks[3] != 0 {
+				pix[4*x+3] = maskValue(v, h.masks[3])
+				hasAlpha = hasAlpha || pix[4*x+3] != 0
+			}
+		}
+	}
+
+	if paletted != nil {
+		return paletted, nil
+	}
+	if !hasAlpha {
+		for i := 3; i < len(nrgba.Pix); i += 4 {
+			nrgba.Pix[i] = 0xff
+		}
+	}
+	return nrgba, nil
+}
+
+// maskValue extracts the masked bits of v and scales it to 8 bits.
+func maskValue(v, mask uint32) uint8 {
+	if mask == 0 {
+		return 0
+	}
+	shift := bits.TrailingZeros32(mask)
+	n := bits.OnesCount32(mask)
+	// the 32 bits mask overflows uint32 when scaled
+	c := uint64((v & mask) >> shift)
+	max := uint64(1)<<n - 1
+	return uint8((c*255 + max/2) / max)
+}
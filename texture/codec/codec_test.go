@@ -0,0 +1,309 @@
+package codec_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/STARRY-S/aperture/texture/codec"
+	"github.com/stretchr/testify/assert"
+)
+
+// bmpFile builds a BMP file with BITMAPINFOHEADER.
+func bmpFile(width, height, bpp int, compression uint32, extra, pixels []byte) []byte {
+	info := make([]byte, 40)
+	binary.LittleEndian.PutUint32(info[0:], 40)
+	binary.LittleEndian.PutUint32(info[4:], uint32(int32(width)))
+	binary.LittleEndian.PutUint32(info[8:], uint32(int32(height)))
+	binary.LittleEndian.PutUint16(info[12:], 1)
+	binary.LittleEndian.PutUint16(info[14:], uint16(bpp))
+	binary.LittleEndian.PutUint32(info[16:], compression)
+	if bpp <= 8 {
+		// number of palette colors
+		binary.LittleEndian.PutUint32(info[32:], uint32(len(extra)/4))
+	}
+
+	offset := 14 + len(info) + len(extra)
+	b := bytes.Buffer{}
+	b.WriteString("BM")
+	binary.Write(&b, binary.LittleEndian, uint32(offset+len(pixels)))
+	binary.Write(&b, binary.LittleEndian, uint32(0))
+	binary.Write(&b, binary.LittleEndian, uint32(offset))
+	b.Write(info)
+	b.Write(extra)
+	b.Write(pixels)
+	return b.Bytes()
+}
+
+func TestDecodeBMP(t *testing.T) {
+	// 2x2 24 bits bottom-up, rows padded to 4 bytes
+	data := bmpFile(2, 2, 24, 0, nil, []byte{
+		0, 0, 255, 0, 255, 0, 0, 0, // bottom row: red, green
+		255, 0, 0, 255, 255, 255, 0, 0, // top row: blue, white
+	})
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, "bmp", format)
+	assert.Equal(t, color.NRGBA{0, 0, 255, 255}, img.At(0, 0))
+	assert.Equal(t, color.NRGBA{255, 255, 255, 255}, img.At(1, 0))
+	assert.Equal(t, color.NRGBA{255, 0, 0, 255}, img.At(0, 1))
+	assert.Equal(t, color.NRGBA{0, 255, 0, 255}, img.At(1, 1))
+
+	// 2x1 8 bits paletted
+	palette := []byte{0, 0, 0, 0, 255, 128, 64, 0}
+	data = bmpFile(2, 1, 8, 0, palette, []byte{1, 0, 0, 0})
+	img, err = codec.DecodeBMP(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	r, g, b, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, []uint32{64, 128, 255}, []uint32{r >> 8, g >> 8, b >> 8})
+
+	// 1x1 32 bits top-down with alpha bit fields
+	masks := make([]byte, 16)
+	for i, m := range []uint32{0xff0000, 0xff00, 0xff, 0xff000000} {
+		binary.LittleEndian.PutUint32(masks[4*i:], m)
+	}
+	data = bmpFile(1, -1, 32, 6, masks, []byte{30, 20, 10, 128})
+	img, err = codec.DecodeBMP(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, color.NRGBA{10, 20, 30, 128}, img.At(0, 0))
+
+	cfg, err := codec.DecodeBMPConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, 1, cfg.Width)
+	assert.Equal(t, 1, cfg.Height)
+}
+
+// bmpInfoFile builds a 1x1 32 bits BMP file with the info header of the
+// size, the bytes after the first 40 bytes of the header are the masks.
+func bmpInfoFile(infoSize int, compression uint32, masks ...uint32) []byte {
+	info := make([]byte, infoSize)
+	binary.LittleEndian.PutUint32(info[0:], uint32(infoSize))
+	binary.LittleEndian.PutUint32(info[4:], 1)
+	binary.LittleEndian.PutUint32(info[8:], 1)
+	binary.LittleEndian.PutUint16(info[12:], 1)
+	binary.LittleEndian.PutUint16(info[14:], 32)
+	binary.LittleEndian.PutUint32(info[16:], compression)
+	for i, m := range masks {
+		if 40+4*i+4 <= infoSize {
+			binary.LittleEndian.PutUint32(info[40+4*i:], m)
+		}
+	}
+
+	offset := 14 + len(info)
+	b := bytes.Buffer{}
+	b.WriteString("BM")
+	binary.Write(&b, binary.LittleEndian, uint32(offset+4))
+	binary.Write(&b, binary.LittleEndian, uint32(0))
+	binary.Write(&b, binary.LittleEndian, uint32(offset))
+	b.Write(info)
+	b.Write([]byte{30, 20, 10, 128})
+	return b.Bytes()
+}
+
+func TestDecodeBMPHeader(t *testing.T) {
+	masks := []uint32{0xff0000, 0xff00, 0xff, 0xff000000}
+
+	// BITMAPV2INFOHEADER has 3 masks, BITMAPV3INFOHEADER has 4 masks
+	img, err := codec.DecodeBMP(bytes.NewReader(bmpInfoFile(52, 3, masks...)))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, color.NRGBA{10, 20, 30, 255}, img.At(0, 0))
+	img, err = codec.DecodeBMP(bytes.NewReader(bmpInfoFile(56, 6, masks...)))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, color.NRGBA{10, 20, 30, 128}, img.At(0, 0))
+
+	// the truncated headers have no room for the masks
+	for size := 41; size < 52; size++ {
+		_, err := codec.DecodeBMP(bytes.NewReader(bmpInfoFile(size, 3, masks...)))
+		assert.Error(t, err, "info header size %d", size)
+	}
+	for size := 41; size < 56; size++ {
+		_, err := codec.DecodeBMP(bytes.NewReader(bmpInfoFile(size, 6, masks...)))
+		assert.Error(t, err, "info header size %d", size)
+	}
+
+	// the 32 bits wide mask
+	img, err = codec.DecodeBMP(bytes.NewReader(bmpInfoFile(52, 3, 0xffffffff, 0, 0)))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, color.NRGBA{128, 0, 0, 255}, img.At(0, 0))
+}
+
+func FuzzDecodeBMP(f *testing.F) {
+	f.Add(bmpFile(1, 1, 24, 0, nil, []byte{1, 2, 3, 0}))
+	for _, size := range []int{41, 48, 52, 54, 56} {
+		f.Add(bmpInfoFile(size, 3, 0xff0000, 0xff00, 0xff))
+		f.Add(bmpInfoFile(size, 6, 0xff0000, 0xff00, 0xff, 0xff000000))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// the malformed files should return errors instead of panicking
+		codec.DecodeBMP(bytes.NewReader(data))
+		codec.DecodeBMPConfig(bytes.NewReader(data))
+	})
+}
+
+func tgaHeader(imageType byte, width, height int, depth, descriptor byte) []byte {
+	h := make([]byte, 18)
+	h[2] = imageType
+	binary.LittleEndian.PutUint16(h[12:], uint16(width))
+	binary.LittleEndian.PutUint16(h[14:], uint16(height))
+	h[16] = depth
+	h[17] = descriptor
+	return h
+}
+
+func TestDecodeTGA(t *testing.T) {
+	// 2x1 uncompressed 24 bits, bottom-left origin
+	data := append(tgaHeader(2, 2, 2, 24, 0), []byte{
+		0, 0, 255, 0, 255, 0, // bottom row: red, green
+		255, 0, 0, 255, 255, 255, // top row: blue, white
+	}...)
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, "tga", format)
+	assert.Equal(t, color.NRGBA{0, 0, 255, 255}, img.At(0, 0))
+	assert.Equal(t, color.NRGBA{0, 255, 0, 255}, img.At(1, 1))
+
+	// 3x1 RLE 32 bits, top-left origin, 8 alpha bits
+	data = append(tgaHeader(10, 3, 1, 32, 0x28), []byte{
+		0x81, 30, 20, 10, 128, // run of 2 pixels
+		0x00, 1, 2, 3, 4, // raw packet of 1 pixel
+	}...)
+	img, err = codec.DecodeTGA(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, color.NRGBA{10, 20, 30, 128}, img.At(1, 0))
+	assert.Equal(t, color.NRGBA{3, 2, 1, 4}, img.At(2, 0))
+
+	// truncated RLE data
+	if _, err := codec.DecodeTGA(bytes.NewReader(data[:len(data)-2])); err == nil {
+		t.Errorf("truncated data should return error")
+	}
+
+	// the header of the large image without the pixel data
+	_, err = codec.DecodeTGA(bytes.NewReader(tgaHeader(2, 0xffff, 0xffff, 32, 8)))
+	assert.Error(t, err)
+	_, err = codec.DecodeTGA(bytes.NewReader(tgaHeader(2, 0x4000, 0x4000, 32, 8)))
+	assert.Error(t, err)
+}
+
+func FuzzDecodeTGA(f *testing.F) {
+	f.Add(append(tgaHeader(2, 1, 1, 24, 0), 1, 2, 3))
+	f.Add(append(tgaHeader(10, 3, 1, 32, 0x28), 0x81, 30, 20, 10, 128, 0x00, 1, 2, 3, 4))
+	f.Add(append(tgaHeader(3, 2, 1, 16, 0), 1, 2, 3, 4))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		codec.DecodeTGA(bytes.NewReader(data))
+		codec.DecodeTGAConfig(bytes.NewReader(data))
+	})
+}
+
+func TestDecodeHDR(t *testing.T) {
+	header := "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 1 +X 8\n"
+
+	// new RLE scanline: each channel is a run of 8 values
+	rle := bytes.Buffer{}
+	rle.WriteString(header)
+	rle.Write([]byte{2, 2, 0, 8})
+	for _, v := range []byte{128, 64, 32, 129} {
+		rle.Write([]byte{128 + 8, v})
+	}
+	img, format, err := image.Decode(bytes.NewReader(rle.Bytes()))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, "hdr", format)
+	f, ok := img.(*codec.RGBAF32)
+	if !ok {
+		t.Fatalf("HDR image should be decoded as *codec.RGBAF32")
+	}
+	// 128 * 2^(129-136) = 1.0
+	assert.Equal(t, [4]float32{1, 0.5, 0.25, 1}, f.FloatAt(7, 0))
+
+	// flat scanline with old RLE repeat
+	flat := bytes.Buffer{}
+	flat.WriteString("#?RGBE\n\n+Y 1 +X 4\n")
+	flat.Write([]byte{128, 128, 128, 130, 1, 1, 1, 3})
+	img, err = codec.DecodeHDR(bytes.NewReader(flat.Bytes()))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, [4]float32{2, 2, 2, 1}, img.(*codec.RGBAF32).FloatAt(3, 0))
+	// values larger than 1 are clamped in At
+	assert.Equal(t, color.RGBA64{0xffff, 0xffff, 0xffff, 0xffff}, img.At(3, 0))
+}
+
+func TestDecodeHDRSize(t *testing.T) {
+	for _, res := range []string{
+		"-Y 4611686018427387904 +X 4",
+		"+Y 1 +X 1000000000",
+		"-Y 65536 +X 65536",
+		"-Y 0 +X 4",
+	} {
+		data := "#?RADIANCE\n\n" + res + "\n" + strings.Repeat("\x80", 16)
+		_, err := codec.DecodeHDR(strings.NewReader(data))
+		assert.Error(t, err, res)
+		_, err = codec.DecodeHDRConfig(strings.NewReader(data))
+		assert.Error(t, err, res)
+	}
+
+	// the truncated data of the large image
+	_, err := codec.DecodeHDR(strings.NewReader("#?RADIANCE\n\n-Y 8192 +X 8192\n"))
+	assert.Error(t, err)
+}
+
+func FuzzDecodeHDR(f *testing.F) {
+	f.Add([]byte("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 1 +X 8\n" +
+		"\x02\x02\x00\x08\x88\x80\x88\x40\x88\x20\x88\x81"))
+	f.Add([]byte("#?RGBE\n\n+Y 1 +X 4\n\x80\x80\x80\x82\x01\x01\x01\x03"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		codec.DecodeHDR(bytes.NewReader(data))
+		codec.DecodeHDRConfig(bytes.NewReader(data))
+	})
+}
+
+func TestEncodeHDR(t *testing.T) {
+	img := codec.NewRGBAF32(image.Rect(0, 0, 3, 2))
+	img.SetFloat(0, 0, [4]float32{1, 0.5, 0.25, 1})
+	img.SetFloat(2, 1, [4]float32{16, 0, 4, 1})
+	buf := bytes.Buffer{}
+	if err := codec.EncodeHDR(&buf, img); err != nil {
+		t.Fatalf(err.Error())
+	}
+	decoded, err := codec.DecodeHDR(&buf)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	f := decoded.(*codec.RGBAF32)
+	assert.Equal(t, img.Rect, f.Rect)
+	assert.Equal(t, [4]float32{1, 0.5, 0.25, 1}, f.FloatAt(0, 0))
+	assert.Equal(t, [4]float32{16, 0, 4, 1}, f.FloatAt(2, 1))
+	assert.Equal(t, [4]float32{0, 0, 0, 1}, f.FloatAt(1, 0))
+
+	// the non-float images are converted to [0, 1]
+	rgba := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	rgba.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	buf.Reset()
+	if err := codec.EncodeHDR(&buf, rgba); err != nil {
+		t.Fatalf(err.Error())
+	}
+	decoded, _ = codec.DecodeHDR(&buf)
+	assert.Equal(t, [4]float32{1, 0, 0, 1}, decoded.(*codec.RGBAF32).FloatAt(0, 0))
+}
@@ -0,0 +1,90 @@
+// Package codec implements the pure-Go decoders of BMP, TGA and Radiance
+// HDR (RGBE) image formats and the encoder of Radiance HDR, the decoders
+// are registered to the image package when this package is imported.
+package codec
+
+import (
+	"image"
+	"image/color"
+)
+
+// RGBAF32 is an in-memory image whose pixels are RGBA float32 values
+// in linear space, the values are not clamped to [0, 1].
+type RGBAF32 struct {
+	// Pix holds the image's pixels, in R, G, B, A order.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*4].
+	Pix []float32
+	// Stride is the Pix stride (in float32 values) between vertically
+	// adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+}
+
+// NewRGBAF32 returns a new RGBAF32 image with the given bounds.
+func NewRGBAF32(r image.Rectangle) *RGBAF32 {
+	w, h := r.Dx(), r.Dy()
+	return &RGBAF32{
+		Pix:    make([]float32, 4*w*h),
+		Stride: 4 * w,
+		Rect:   r,
+	}
+}
+
+func (p *RGBAF32) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+func (p *RGBAF32) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At gets the color of the pixel clamped to [0, 1], the color is
+// premultiplied by alpha as required by color.Color.
+func (p *RGBAF32) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return color.RGBA64{}
+	}
+	v := p.FloatAt(x, y)
+	a := clamp(v[3])
+	return color.RGBA64{
+		R: uint16(clamp(v[0])*a*0xffff + 0.5),
+		G: uint16(clamp(v[1])*a*0xffff + 0.5),
+		B: uint16(clamp(v[2])*a*0xffff + 0.5),
+		A: uint16(a*0xffff + 0.5),
+	}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds
+// to the pixel at (x, y).
+func (p *RGBAF32) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*4
+}
+
+// FloatAt gets the unclamped RGBA value of the pixel.
+func (p *RGBAF32) FloatAt(x, y int) [4]float32 {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return [4]float32{}
+	}
+	i := p.PixOffset(x, y)
+	return [4]float32{p.Pix[i], p.Pix[i+1], p.Pix[i+2], p.Pix[i+3]}
+}
+
+// SetFloat sets the unclamped RGBA value of the pixel.
+func (p *RGBAF32) SetFloat(x, y int, v [4]float32) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	copy(p.Pix[i:i+4], v[:])
+}
+
+func clamp(v float32) float32 {
+	if v < 0 || v != v {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,274 @@
+package codec
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"strings"
+)
+
+var errHDRInvalid = errors.New("hdr: invalid format")
+
+const (
+	// hdrMaxSize is the maximum width and height of the HDR image.
+	hdrMaxSize = 1 << 16
+	// hdrMaxPixels is the maximum number of pixels of the HDR image.
+	hdrMaxPixels = 1 << 27
+)
+
+func init() {
+	image.RegisterFormat("hdr", "#?RADIANCE", DecodeHDR, DecodeHDRConfig)
+	image.RegisterFormat("hdr", "#?RGBE", DecodeHDR, DecodeHDRConfig)
+}
+
+type hdrHeader struct {
+	width  int
+	height int
+	// flipY is true if the scanlines are stored from bottom to top
+	flipY bool
+}
+
+func readHDRHeader(r *bufio.Reader) (*hdrHeader, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("hdr: %w", err)
+	}
+	if !strings.HasPrefix(line, "#?") {
+		return nil, errHDRInvalid
+	}
+
+	// header lines end with an empty line
+	for {
+		line, err = r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("hdr: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("hdr: unsupported %s", line)
+		}
+	}
+
+	// resolution string, e.g. "-Y 512 +X 1024"
+	line, err = r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("hdr: %w", err)
+	}
+	var ys, xs string
+	h := &hdrHeader{}
+	if _, err := fmt.Sscanf(line, "%s %d %s %d", &ys, &h.height, &xs, &h.width); err != nil {
+		return nil, errHDRInvalid
+	}
+	switch {
+	case ys == "-Y" && xs == "+X":
+	case ys == "+Y" && xs == "+X":
+		h.flipY = true
+	default:
+		return nil, fmt.Errorf("hdr: unsupported orientation %q", strings.TrimSpace(line))
+	}
+	if h.width <= 0 || h.height <= 0 || h.width > hdrMaxSize || h.height > hdrMaxSize ||
+		h.width > hdrMaxPixels/h.height {
+		return nil, errors.New("hdr: invalid image size")
+	}
+	return h, nil
+}
+
+// DecodeHDRConfig returns the color model and dimensions of a Radiance HDR
+// image without decoding the entire image.
+func DecodeHDRConfig(r io.Reader) (image.Config, error) {
+	h, err := readHDRHeader(bufio.NewReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{
+		ColorModel: (&RGBAF32{}).ColorModel(),
+		Width:      h.width,
+		Height:     h.height,
+	}, nil
+}
+
+// DecodeHDR reads a Radiance HDR (RGBE) image from r and returns it as an
+// *RGBAF32 in linear space, the alpha of the pixels is 1.
+func DecodeHDR(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	h, err := readHDRHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	// decode the scanlines before allocating the image, so the size in the
+	// header of a truncated file does not allocate the memory up front
+	var pix []float32
+	scanline := make([]byte, h.width*4)
+	for i := 0; i < h.height; i++ {
+		if err := readHDRScanline(br, scanline); err != nil {
+			return nil, err
+		}
+		for x := 0; x < h.width; x++ {
+			r, g, b := rgbeToFloat(scanline[4*x:])
+			pix = append(pix, r, g, b, 1)
+		}
+	}
+
+	img := &RGBAF32{
+		Pix:    pix,
+		Stride: 4 * h.width,
+		Rect:   image.Rect(0, 0, h.width, h.height),
+	}
+	if h.flipY {
+		row := make([]float32, img.Stride)
+		for y := 0; y < h.height/2; y++ {
+			top := pix[y*img.Stride : (y+1)*img.Stride]
+			bottom := pix[(h.height-1-y)*img.Stride : (h.height-y)*img.Stride]
+			copy(row, top)
+			copy(top, bottom)
+			copy(bottom, row)
+		}
+	}
+	return img, nil
+}
+
+// readHDRScanline reads one scanline in flat, old RLE or new RLE encoding.
+func readHDRScanline(r *bufio.Reader, scanline []byte) error {
+	width := len(scanline) / 4
+	head, err := r.Peek(4)
+	if err != nil {
+		return fmt.Errorf("hdr: %w", err)
+	}
+	if width < 8 || width > 0x7fff || head[0] != 2 || head[1] != 2 || head[2]&0x80 != 0 {
+		return readHDRFlat(r, scanline)
+	}
+	if int(head[2])<<8|int(head[3]) != width {
+		return errHDRInvalid
+	}
+	r.Discard(4)
+
+	// new RLE: four channels are encoded separately
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; {
+			count, err := r.ReadByte()
+			if err != nil {
+				return fmt.Errorf("hdr: %w", err)
+			}
+			if count > 128 {
+				n := int(count - 128)
+				v, err := r.ReadByte()
+				if err != nil {
+					return fmt.Errorf("hdr: %w", err)
+				}
+				if x+n > width {
+					return errHDRInvalid
+				}
+				for ; n > 0; n-- {
+					scanline[4*x+c] = v
+					x++
+				}
+			} else {
+				n := int(count)
+				if n == 0 || x+n > width {
+					return errHDRInvalid
+				}
+				for ; n > 0; n-- {
+					v, err := r.ReadByte()
+					if err != nil {
+						return fmt.Errorf("hdr: %w", err)
+					}
+					scanline[4*x+c] = v
+					x++
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readHDRFlat reads the uncompressed pixels with the old RLE repeat markers.
+func readHDRFlat(r *bufio.Reader, scanline []byte) error {
+	shift := 0
+	for pos := 0; pos < len(scanline); {
+		if _, err := io.ReadFull(r, scanline[pos:pos+4]); err != nil {
+			return fmt.Errorf("hdr: %w", err)
+		}
+		p := scanline[pos : pos+4]
+		if p[0] == 1 && p[1] == 1 && p[2] == 1 {
+			// old RLE: repeat the previous pixel
+			if pos == 0 {
+				return errHDRInvalid
+			}
+			n := int(p[3]) << shift
+			if pos+n*4 > len(scanline) {
+				return errHDRInvalid
+			}
+			prev := scanline[pos-4 : pos]
+			for ; n > 0; n-- {
+				copy(scanline[pos:], prev)
+				pos += 4
+			}
+			shift += 8
+			continue
+		}
+		shift = 0
+		pos += 4
+	}
+	return nil
+}
+
+// EncodeHDR writes the image to w in Radiance HDR (RGBE) format with flat
+// scanlines, the colors of the non-float images are converted to linear
+// values in [0, 1] without gamma correction, the alpha is dropped.
+func EncodeHDR(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %d +X %d\n", b.Dy(), b.Dx())
+	f, isFloat := img.(*RGBAF32)
+	scanline := make([]byte, b.Dx()*4)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var v [4]float32
+			if isFloat {
+				v = f.FloatAt(x, y)
+			} else {
+				r, g, b, _ := img.At(x, y).RGBA()
+				v = [4]float32{float32(r) / 0xffff, float32(g) / 0xffff, float32(b) / 0xffff}
+			}
+			floatToRGBE(v[0], v[1], v[2], scanline[4*(x-b.Min.X):])
+		}
+		if _, err := bw.Write(scanline); err != nil {
+			return fmt.Errorf("hdr: %w", err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("hdr: %w", err)
+	}
+	return nil
+}
+
+// floatToRGBE converts the linear float RGB values to the RGBE pixel.
+func floatToRGBE(r, g, b float32, p []byte) {
+	v := math.Max(float64(r), math.Max(float64(g), float64(b)))
+	if v < 1e-32 || math.IsNaN(v) {
+		p[0], p[1], p[2], p[3] = 0, 0, 0, 0
+		return
+	}
+	m, e := math.Frexp(v)
+	scale := m * 256 / v
+	p[0] = byte(math.Max(float64(r), 0) * scale)
+	p[1] = byte(math.Max(float64(g), 0) * scale)
+	p[2] = byte(math.Max(float64(b), 0) * scale)
+	p[3] = byte(e + 128)
+}
+
+// rgbeToFloat converts the RGBE pixel to linear float RGB values.
+func rgbeToFloat(p []byte) (r, g, b float32) {
+	if p[3] == 0 {
+		return 0, 0, 0
+	}
+	f := float32(math.Ldexp(1, int(p[3])-(128+8)))
+	return float32(p[0]) * f, float32(p[1]) * f, float32(p[2]) * f
+}
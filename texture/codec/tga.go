@@ -0,0 +1,245 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+var errTGAUnsupported = errors.New("tga: unsupported format")
+
+const (
+	tgaHeaderSize = 18
+
+	tgaColorMapped    = 1
+	tgaTrueColor      = 2
+	tgaGrayscale      = 3
+	tgaRLEColorMapped = 9
+	tgaRLETrueColor   = 10
+	tgaRLEGrayscale   = 11
+
+	// tgaMaxPixels is the maximum number of pixels of the TGA image.
+	tgaMaxPixels = 1 << 28
+)
+
+func init() {
+	// TGA has no magic number, register the header patterns of
+	// (color map type, image type) instead, "?" matches the ID length.
+	for _, magic := range []string{
+		"?\x01\x01", "?\x00\x02", "?\x00\x03",
+		"?\x01\x09", "?\x00\x0a", "?\x00\x0b",
+	} {
+		image.RegisterFormat("tga", magic, DecodeTGA, DecodeTGAConfig)
+	}
+}
+
+type tgaHeader struct {
+	idLength     int
+	colorMapType int
+	imageType    int
+	mapFirst     int
+	mapLength    int
+	mapDepth     int
+	width        int
+	height       int
+	depth        int
+	descriptor   byte
+}
+
+func readTGAHeader(r io.Reader) (*tgaHeader, error) {
+	var b [tgaHeaderSize]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return nil, err
+	}
+	h := &tgaHeader{
+		idLength:     int(b[0]),
+		colorMapType: int(b[1]),
+		imageType:    int(b[2]),
+		mapFirst:     int(binary.LittleEndian.Uint16(b[3:5])),
+		mapLength:    int(binary.LittleEndian.Uint16(b[5:7])),
+		mapDepth:     int(b[7]),
+		width:        int(binary.LittleEndian.Uint16(b[12:14])),
+		height:       int(binary.LittleEndian.Uint16(b[14:16])),
+		depth:        int(b[16]),
+		descriptor:   b[17],
+	}
+	if h.width == 0 || h.height == 0 || h.width*h.height > tgaMaxPixels {
+		return nil, errors.New("tga: invalid image size")
+	}
+	switch h.imageType {
+	case tgaColorMapped, tgaRLEColorMapped:
+		if h.colorMapType != 1 || h.depth != 8 {
+			return nil, errTGAUnsupported
+		}
+	case tgaTrueColor, tgaRLETrueColor:
+		if h.depth != 15 && h.depth != 16 && h.depth != 24 && h.depth != 32 {
+			return nil, errTGAUnsupported
+		}
+	case tgaGrayscale, tgaRLEGrayscale:
+		if h.depth != 8 && h.depth != 16 {
+			return nil, errTGAUnsupported
+		}
+	default:
+		return nil, errTGAUnsupported
+	}
+	return h, nil
+}
+
+// DecodeTGAConfig returns the color model and dimensions of a TGA image
+// without decoding the entire image.
+func DecodeTGAConfig(r io.Reader) (image.Config, error) {
+	h, err := readTGAHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{
+		ColorModel: color.NRGBAModel,
+		Width:      h.width,
+		Height:     h.height,
+	}, nil
+}
+
+// DecodeTGA reads a TGA image from r and returns it as an *image.NRGBA,
+// the uncompressed and RLE compressed color-mapped, true-color and
+// grayscale images are supported.
+func DecodeTGA(r io.Reader) (image.Image, error) {
+	h, err := readTGAHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(h.idLength)); err != nil {
+		return nil, fmt.Errorf("tga: %w", err)
+	}
+
+	// read the color map, the color map may exist in true-color images
+	var palette []color.NRGBA
+	if h.colorMapType == 1 {
+		entrySize := (h.mapDepth + 7) / 8
+		buf := make([]byte, h.mapLength*entrySize)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("tga: %w", err)
+		}
+		palette = make([]color.NRGBA, h.mapLength)
+		for i := range palette {
+			palette[i] = tgaColor(buf[i*entrySize:], h.mapDepth, h.mapDepth == 32)
+		}
+	}
+
+	pixelSize := (h.depth + 7) / 8
+	rle := h.imageType >= tgaRLEColorMapped
+	gray := h.imageType == tgaGrayscale || h.imageType == tgaRLEGrayscale
+	// alpha bits of 32 bits and 16 bits images
+	alpha := h.descriptor&0x0f != 0
+
+	// read the pixel data before allocating the image, so the size in the
+	// header of a truncated file does not allocate the memory up front
+	size := h.width * h.height * pixelSize
+	data := bytes.Buffer{}
+	if rle {
+		if err := readTGARLE(r, &data, size, pixelSize); err != nil {
+			return nil, err
+		}
+	} else if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("tga: %w", err)
+	}
+	pix := data.Bytes()
+
+	img := image.NewNRGBA(image.Rect(0, 0, h.width, h.height))
+	rightToLeft := h.descriptor&0x10 != 0
+	topToBottom := h.descriptor&0x20 != 0
+	for i := 0; i < h.width*h.height; i++ {
+		x, y := i%h.width, i/h.width
+		if rightToLeft {
+			x = h.width - 1 - x
+		}
+		if !topToBottom {
+			y = h.height - 1 - y
+		}
+		p := pix[i*pixelSize:]
+
+		var c color.NRGBA
+		switch {
+		case palette != nil && !gray && h.depth == 8:
+			index := int(p[0]) - h.mapFirst
+			if index >= 0 && index < len(palette) {
+				c = palette[index]
+			}
+		case gray:
+			c = color.NRGBA{p[0], p[0], p[0], 0xff}
+			if pixelSize == 2 {
+				c.A = p[1]
+			}
+		default:
+			c = tgaColor(p, h.depth, alpha)
+		}
+		img.SetNRGBA(x, y, c)
+	}
+	return img, nil
+}
+
+// readTGARLE decodes the run-length encoded packets of size bytes into data.
+func readTGARLE(r io.Reader, data *bytes.Buffer, size, pixelSize int) error {
+	var header [1]byte
+	pixel := make([]byte, pixelSize)
+	for data.Len() < size {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return fmt.Errorf("tga: %w", err)
+		}
+		count := int(header[0]&0x7f) + 1
+		if data.Len()+count*pixelSize > size {
+			return errors.New("tga: invalid RLE packet")
+		}
+		if header[0]&0x80 != 0 {
+			// run-length packet
+			if _, err := io.ReadFull(r, pixel); err != nil {
+				return fmt.Errorf("tga: %w", err)
+			}
+			for i := 0; i < count; i++ {
+				data.Write(pixel)
+			}
+		} else {
+			// raw packet
+			if _, err := io.CopyN(data, r, int64(count*pixelSize)); err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return fmt.Errorf("tga: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// tgaColor converts the BGR(A) pixel to color.
+func tgaColor(p []byte, depth int, alpha bool) color.NRGBA {
+	switch depth {
+	case 15, 16:
+		v := binary.LittleEndian.Uint16(p)
+		c := color.NRGBA{
+			R: uint8((v >> 10 & 0x1f) * 255 / 31),
+			G: uint8((v >> 5 & 0x1f) * 255 / 31),
+			B: uint8((v & 0x1f) * 255 / 31),
+			A: 0xff,
+		}
+		if depth == 16 && alpha && v&0x8000 == 0 {
+			c.A = 0
+		}
+		return c
+	case 24:
+		return color.NRGBA{p[2], p[1], p[0], 0xff}
+	case 32:
+		a := p[3]
+		if !alpha {
+			a = 0xff
+		}
+		return color.NRGBA{p[2], p[1], p[0], a}
+	}
+	return color.NRGBA{}
+}
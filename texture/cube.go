@@ -0,0 +1,430 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/STARRY-S/aperture/texture/codec"
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// CubeLayout is the layout of the cube map faces in one image.
+type CubeLayout int
+
+const (
+	// CubeLayoutAuto detects the layout by the aspect ratio of the image:
+	// 4:3 is horizontal cross, 3:4 is vertical cross and 2:1 is
+	// equirectangular panorama.
+	CubeLayoutAuto CubeLayout = iota
+	// CubeLayoutHorizontalCross is the 4x3 cross layout:
+	//
+	//	    +Y
+	//	-X  +Z  +X  -Z
+	//	    -Y
+	CubeLayoutHorizontalCross
+	// CubeLayoutVerticalCross is the 3x4 cross layout, the -Z face is
+	// rotated by 180 degrees:
+	//
+	//	    +Y
+	//	-X  +Z  +X
+	//	    -Y
+	//	    -Z
+	CubeLayoutVerticalCross
+	// CubeLayoutEquirect is the 2:1 equirectangular (latitude-longitude)
+	// panorama, it is converted to cube faces on the CPU.
+	CubeLayoutEquirect
+)
+
+// The cube map faces in the OpenGL order.
+const (
+	CubeFacePositiveX = iota
+	CubeFaceNegativeX
+	CubeFacePositiveY
+	CubeFaceNegativeY
+	CubeFacePositiveZ
+	CubeFaceNegativeZ
+)
+
+// CubeTextureObj is the cube map texture (GL_TEXTURE_CUBE_MAP),
+// it implements the Texture interface.
+type CubeTextureObj struct {
+	fileName string
+	id       uint32
+	size     int32
+	format   Format
+	layout   CubeLayout
+
+	// param is the sampling parameters used when loading the texture
+	param TextureParam
+}
+
+// Load loads the cube map from one image file in the layout set by
+// SetLayout, the HDR images are loaded as float textures.
+func (c *CubeTextureObj) Load(f string) error {
+	img, err := loadImage(f)
+	if err != nil {
+		return fmt.Errorf("Load: %w", err)
+	}
+	faces, err := SplitCubeImage(img, c.layout)
+	if err != nil {
+		return fmt.Errorf("Load: %w", err)
+	}
+	if err := c.upload(faces); err != nil {
+		return fmt.Errorf("Load: %w", err)
+	}
+	c.fileName = f
+	return nil
+}
+
+// LoadFaces loads the cube map from six square image files of the same
+// size, in +X, -X, +Y, -Y, +Z, -Z order.
+func (c *CubeTextureObj) LoadFaces(files [6]string) error {
+	var faces [6]image.Image
+	for i, f := range files {
+		img, err := loadImage(f)
+		if err != nil {
+			return fmt.Errorf("LoadFaces: %w", err)
+		}
+		faces[i] = img
+	}
+	if err := c.upload(faces); err != nil {
+		return fmt.Errorf("LoadFaces: %w", err)
+	}
+	c.fileName = files[0]
+	return nil
+}
+
+// LoadMemory loads the cube map from the tightly packed RGBA8 image in
+// memory in the layout set by SetLayout.
+func (c *CubeTextureObj) LoadMemory(width, height int, data *[]byte) error {
+	if data == nil {
+		return fmt.Errorf("LoadMemory: %w", utils.ErrInvalidParameter)
+	}
+	if err := LayoutRGBA8.validate(width, height, len(*data)); err != nil {
+		return fmt.Errorf("LoadMemory: %w", err)
+	}
+	img := &image.RGBA{
+		Pix:    *data,
+		Stride: width * 4,
+		Rect:   image.Rect(0, 0, width, height),
+	}
+	faces, err := SplitCubeImage(img, c.layout)
+	if err != nil {
+		return fmt.Errorf("LoadMemory: %w", err)
+	}
+	if err := c.upload(faces); err != nil {
+		return fmt.Errorf("LoadMemory: %w", err)
+	}
+	return nil
+}
+
+// upload uploads the faces to a new cube map texture, faces are
+// *image.RGBA or *codec.RGBAF32 square images of the same size.
+func (c *CubeTextureObj) upload(faces [6]image.Image) error {
+	p, err := c.param.normalize()
+	if err != nil {
+		return err
+	}
+	size := faces[0].Bounds().Dx()
+	var pixels [6]interface{}
+	var l PixelLayout
+	for i, face := range faces {
+		b := face.Bounds()
+		if b.Dx() != size || b.Dy() != size || size == 0 {
+			return fmt.Errorf("face %d is not %dx%d square: %w",
+				i, size, size, utils.ErrInvalidParameter)
+		}
+		fl := LayoutRGBA8
+		switch img := face.(type) {
+		case *codec.RGBAF32:
+			pixels[i], fl = img.Pix, LayoutRGBA32F
+		case *image.RGBA:
+			pixels[i] = img.Pix
+		default:
+			rgba := image.NewRGBA(image.Rect(0, 0, size, size))
+			draw.Draw(rgba, rgba.Bounds(), face, b.Min, draw.Src)
+			pixels[i] = rgba.Pix
+		}
+		if i > 0 && fl != l {
+			return fmt.Errorf("faces have different pixel types: %w",
+				utils.ErrInvalidParameter)
+		}
+		l = fl
+	}
+	format := p.Format
+	if format == FormatAuto {
+		format = Format(l.glInternalFormat())
+	}
+	if format.IsDepth() {
+		return fmt.Errorf("depth format is not supported: %w", utils.ErrInvalidParameter)
+	}
+
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, texture)
+	applyTextureParam(gl.TEXTURE_CUBE_MAP, p)
+	for i := range pixels {
+		gl.TexImage2D(
+			gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i),
+			0,
+			int32(format),
+			int32(size),
+			int32(size),
+			0,
+			l.glFormat(),
+			l.glType(),
+			gl.Ptr(pixels[i]))
+	}
+	if p.Mipmap {
+		gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	}
+	if texture == 0 {
+		return fmt.Errorf("failed to create cube map texture")
+	}
+	c.Release()
+	c.id, c.size, c.format = texture, int32(size), format
+	return nil
+}
+
+func (c *CubeTextureObj) GetID() uint32 {
+	return c.id
+}
+
+// GetTarget gets the texture target GL_TEXTURE_CUBE_MAP.
+func (c *CubeTextureObj) GetTarget() uint32 {
+	return gl.TEXTURE_CUBE_MAP
+}
+
+// GetSize gets the width (and height) of each face.
+func (c *CubeTextureObj) GetSize() int32 {
+	return c.size
+}
+
+// GetFormat gets the internal format of the loaded texture.
+func (c *CubeTextureObj) GetFormat() Format {
+	return c.format
+}
+
+// SetLayout sets the layout of the image loaded by Load and LoadMemory.
+func (c *CubeTextureObj) SetLayout(l CubeLayout) {
+	c.layout = l
+}
+
+func (c *CubeTextureObj) GetLayout() CubeLayout {
+	return c.layout
+}
+
+// SetParam sets the sampling parameters used when loading the texture.
+func (c *CubeTextureObj) SetParam(p TextureParam) error {
+	if _, err := p.normalize(); err != nil {
+		return fmt.Errorf("SetParam: %w", err)
+	}
+	c.param = p
+	return nil
+}
+
+func (c *CubeTextureObj) GetParam() TextureParam {
+	return c.param
+}
+
+// Release deletes the OpenGL texture.
+func (c *CubeTextureObj) Release() {
+	if c.id == 0 {
+		return
+	}
+	gl.DeleteTextures(1, &c.id)
+	c.id = 0
+}
+
+func (c *CubeTextureObj) SetFileName(name string) {
+	c.fileName = name
+}
+
+func (c *CubeTextureObj) GetFileName() string {
+	return c.fileName
+}
+
+// SplitCubeImage splits the image in the layout into six square cube map
+// faces in +X, -X, +Y, -Y, +Z, -Z order. The faces are *codec.RGBAF32 if
+// img is *codec.RGBAF32, otherwise the faces are *image.RGBA.
+func SplitCubeImage(img image.Image, layout CubeLayout) ([6]image.Image, error) {
+	var faces [6]image.Image
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if layout == CubeLayoutAuto {
+		switch {
+		case w*3 == h*4:
+			layout = CubeLayoutHorizontalCross
+		case w*4 == h*3:
+			layout = CubeLayoutVerticalCross
+		case w == h*2:
+			layout = CubeLayoutEquirect
+		default:
+			return faces, fmt.Errorf("SplitCubeImage: unknown layout of %dx%d image: %w",
+				w, h, utils.ErrInvalidParameter)
+		}
+	}
+
+	// cells are the (column, row) of faces in the cross layouts
+	var cells [6][2]int
+	var size int
+	switch layout {
+	case CubeLayoutHorizontalCross:
+		cells = [6][2]int{{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {3, 1}}
+		size = w / 4
+		if w != size*4 || h != size*3 {
+			size = 0
+		}
+	case CubeLayoutVerticalCross:
+		cells = [6][2]int{{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {1, 3}}
+		size = w / 3
+		if w != size*3 || h != size*4 {
+			size = 0
+		}
+	case CubeLayoutEquirect:
+		size = w / 4
+		if w != h*2 {
+			size = 0
+		}
+	default:
+		return faces, fmt.Errorf("SplitCubeImage: invalid layout %d: %w",
+			layout, utils.ErrInvalidParameter)
+	}
+	if size == 0 {
+		return faces, fmt.Errorf("SplitCubeImage: invalid image size %dx%d: %w",
+			w, h, utils.ErrInvalidParameter)
+	}
+
+	if f, ok := img.(*codec.RGBAF32); ok {
+		for i := range faces {
+			face := codec.NewRGBAF32(image.Rect(0, 0, size, size))
+			if layout == CubeLayoutEquirect {
+				equirectFace(face.Pix, f.Pix[f.PixOffset(b.Min.X, b.Min.Y):], f.Stride, w, h, i, size)
+			} else {
+				flip := layout == CubeLayoutVerticalCross && i == CubeFaceNegativeZ
+				cropFace(face.Pix, f.Pix, f.Stride,
+					f.PixOffset(b.Min.X+cells[i][0]*size, b.Min.Y+cells[i][1]*size), size, flip)
+			}
+			faces[i] = face
+		}
+		return faces, nil
+	}
+
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+		b = rgba.Rect
+	}
+	for i := range faces {
+		face := image.NewRGBA(image.Rect(0, 0, size, size))
+		if layout == CubeLayoutEquirect {
+			equirectFace(face.Pix, rgba.Pix[rgba.PixOffset(b.Min.X, b.Min.Y):], rgba.Stride, w, h, i, size)
+		} else {
+			flip := layout == CubeLayoutVerticalCross && i == CubeFaceNegativeZ
+			cropFace(face.Pix, rgba.Pix, rgba.Stride,
+				rgba.PixOffset(b.Min.X+cells[i][0]*size, b.Min.Y+cells[i][1]*size), size, flip)
+		}
+		faces[i] = face
+	}
+	return faces, nil
+}
+
+// cropFace copies the size x size RGBA square starting at offset of src
+// into dst, rotates it by 180 degrees if flip is true.
+func cropFace[T uint8 | float32](dst, src []T, stride, offset, size int, flip bool) {
+	for y := 0; y < size; y++ {
+		row := src[offset+y*stride : offset+y*stride+size*4]
+		if !flip {
+			copy(dst[y*size*4:], row)
+			continue
+		}
+		dy := size - 1 - y
+		for x := 0; x < size; x++ {
+			dx := size - 1 - x
+			copy(dst[(dy*size+dx)*4:(dy*size+dx)*4+4], row[x*4:x*4+4])
+		}
+	}
+}
+
+// cubeDirection gets the direction of the texel on the face,
+// s and t are in [-1, 1], t points down in the face image.
+func cubeDirection(face int, s, t float64) (x, y, z float64) {
+	switch face {
+	case CubeFacePositiveX:
+		return 1, -t, -s
+	case CubeFaceNegativeX:
+		return -1, -t, s
+	case CubeFacePositiveY:
+		return s, 1, t
+	case CubeFaceNegativeY:
+		return s, -1, -t
+	case CubeFacePositiveZ:
+		return s, -t, 1
+	default:
+		return -s, -t, -1
+	}
+}
+
+// equirectFace samples the cube face from the w x h equirectangular
+// RGBA panorama with bilinear filtering.
+func equirectFace[T uint8 | float32](dst, src []T, stride, w, h, face, size int) {
+	for py := 0; py < size; py++ {
+		for px := 0; px < size; px++ {
+			s := 2*(float64(px)+0.5)/float64(size) - 1
+			t := 2*(float64(py)+0.5)/float64(size) - 1
+			x, y, z := cubeDirection(face, s, t)
+			l := math.Sqrt(x*x + y*y + z*z)
+			// longitude 0 at -Z, latitude 0 at +Y
+			u := (math.Atan2(x, -z)/math.Pi + 1) / 2
+			v := math.Acos(y/l) / math.Pi
+
+			fx := u*float64(w) - 0.5
+			fy := v*float64(h) - 0.5
+			x0 := int(math.Floor(fx))
+			y0 := int(math.Floor(fy))
+			ax, ay := fx-float64(x0), fy-float64(y0)
+			// wrap horizontally, clamp vertically
+			x0w, x1w := (x0%w+w)%w, ((x0+1)%w+w)%w
+			y0c, y1c := clampInt(y0, 0, h-1), clampInt(y0+1, 0, h-1)
+
+			for c := 0; c < 4; c++ {
+				p00 := float64(src[y0c*stride+x0w*4+c])
+				p10 := float64(src[y0c*stride+x1w*4+c])
+				p01 := float64(src[y1c*stride+x0w*4+c])
+				p11 := float64(src[y1c*stride+x1w*4+c])
+				v := (p00*(1-ax)+p10*ax)*(1-ay) + (p01*(1-ax)+p11*ax)*ay
+				var zero T
+				if _, ok := any(zero).(uint8); ok {
+					v = math.Round(v)
+				}
+				dst[(py*size+px)*4+c] = T(v)
+			}
+		}
+	}
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func NewCubeTextureObj(p *TextureParam) (*CubeTextureObj, error) {
+	c := CubeTextureObj{}
+	if p != nil {
+		if _, err := p.normalize(); err != nil {
+			return nil, fmt.Errorf("NewCubeTextureObj: %w", err)
+		}
+		c.param = *p
+	}
+	return &c, nil
+}
@@ -0,0 +1,103 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/STARRY-S/aperture/texture/codec"
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Download reads the texture image back from OpenGL, the rows are in the
+// same order as uploaded (the first row of the loaded image file is the
+// first row of the downloaded image).
+//
+// The float textures are downloaded as *codec.RGBAF32, the depth textures
+// are downloaded as *image.Gray16, others are downloaded as *image.RGBA,
+// the missing channels are filled by 0 (green, blue) and 1 (alpha). The
+// colors of the sRGB textures are not converted.
+func (t *TextureObj) Download() (image.Image, error) {
+	if t.id == 0 {
+		return nil, fmt.Errorf("Download: texture not loaded: %w", utils.ErrInvalidPointer)
+	}
+	width, height := int(t.width), int(t.height)
+	rect := image.Rect(0, 0, width, height)
+
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, t.id)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	defer gl.PixelStorei(gl.PACK_ALIGNMENT, 4)
+	switch {
+	case t.format.IsFloat():
+		img := codec.NewRGBAF32(rect)
+		gl.GetTexImage(gl.TEXTURE_2D, 0, gl.RGBA, gl.FLOAT, gl.Ptr(img.Pix))
+		return img, nil
+	case t.format.IsDepth():
+		depth := make([]float32, width*height)
+		gl.GetTexImage(gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT, gl.FLOAT, gl.Ptr(depth))
+		img := image.NewGray16(rect)
+		for i, d := range depth {
+			v := uint16(d*0xffff + 0.5)
+			img.Pix[2*i], img.Pix[2*i+1] = uint8(v>>8), uint8(v)
+		}
+		return img, nil
+	}
+	img := image.NewRGBA(rect)
+	gl.GetTexImage(gl.TEXTURE_2D, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	return img, nil
+}
+
+// Save downloads the texture and saves it to the image file, see SaveImage
+// for the supported formats.
+func (t *TextureObj) Save(file string) error {
+	img, err := t.Download()
+	if err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	if err := SaveImage(file, img); err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	return nil
+}
+
+// SaveImage saves the image to the file in the format of the file
+// extension: ".png", ".jpg" (".jpeg") or ".hdr". The float images saved to
+// PNG or JPEG are clamped to [0, 1].
+func SaveImage(file string, img image.Image) error {
+	if img == nil {
+		return fmt.Errorf("SaveImage: %w", utils.ErrInvalidPointer)
+	}
+	ext := strings.ToLower(filepath.Ext(file))
+	switch ext {
+	case ".png", ".jpg", ".jpeg", ".hdr":
+	default:
+		return fmt.Errorf("SaveImage: unsupported file extension %q: %w",
+			ext, utils.ErrInvalidFilePath)
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("SaveImage: %w", err)
+	}
+	switch ext {
+	case ".png":
+		err = png.Encode(f, img)
+	case ".jpg", ".jpeg":
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: 95})
+	case ".hdr":
+		err = codec.EncodeHDR(f, img)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("SaveImage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package texture
+
+import (
+	"fmt"
+
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Format is the sized internal format of the texture.
+type Format int32
+
+const (
+	// FormatAuto uses the internal format matching the pixel data,
+	// e.g. RGBA8 for JPEG and PNG images, RGBA32F for HDR images.
+	FormatAuto Format = 0
+
+	FormatR8    Format = gl.R8
+	FormatRG8   Format = gl.RG8
+	FormatRGB8  Format = gl.RGB8
+	FormatRGBA8 Format = gl.RGBA8
+
+	// FormatSRGB8 and FormatSRGB8Alpha8 store the color in sRGB space, the
+	// color is converted to linear space when sampled, use them for the
+	// albedo (diffuse) textures.
+	FormatSRGB8       Format = gl.SRGB8
+	FormatSRGB8Alpha8 Format = gl.SRGB8_ALPHA8
+
+	FormatR16F    Format = gl.R16F
+	FormatRG16F   Format = gl.RG16F
+	FormatRGB16F  Format = gl.RGB16F
+	FormatRGBA16F Format = gl.RGBA16F
+	FormatR32F    Format = gl.R32F
+	FormatRG32F   Format = gl.RG32F
+	FormatRGB32F  Format = gl.RGB32F
+	FormatRGBA32F Format = gl.RGBA32F
+
+	// The depth and depth-stencil formats can only be allocated by
+	// TextureObj.Alloc, they are used for shadow maps and render targets.
+	FormatDepth16          Format = gl.DEPTH_COMPONENT16
+	FormatDepth24          Format = gl.DEPTH_COMPONENT24
+	FormatDepth32F         Format = gl.DEPTH_COMPONENT32F
+	FormatDepth24Stencil8  Format = gl.DEPTH24_STENCIL8
+	FormatDepth32FStencil8 Format = gl.DEPTH32F_STENCIL8
+)
+
+// IsDepth reports whether the format is a depth or depth-stencil format.
+func (f Format) IsDepth() bool {
+	switch f {
+	case FormatDepth16, FormatDepth24, FormatDepth32F,
+		FormatDepth24Stencil8, FormatDepth32FStencil8:
+		return true
+	}
+	return false
+}
+
+// IsStencil reports whether the format has the stencil component.
+func (f Format) IsStencil() bool {
+	return f == FormatDepth24Stencil8 || f == FormatDepth32FStencil8
+}
+
+// IsSRGB reports whether the format stores the color in sRGB space.
+func (f Format) IsSRGB() bool {
+	return f == FormatSRGB8 || f == FormatSRGB8Alpha8
+}
+
+// IsFloat reports whether the format stores the float color values.
+func (f Format) IsFloat() bool {
+	switch f {
+	case FormatR16F, FormatRG16F, FormatRGB16F, FormatRGBA16F,
+		FormatR32F, FormatRG32F, FormatRGB32F, FormatRGBA32F:
+		return true
+	}
+	return false
+}
+
+func (f Format) validate() error {
+	switch f {
+	case FormatAuto, FormatR8, FormatRG8, FormatRGB8, FormatRGBA8,
+		FormatSRGB8, FormatSRGB8Alpha8:
+		return nil
+	}
+	if f.IsFloat() || f.IsDepth() {
+		return nil
+	}
+	return fmt.Errorf("invalid internal format 0x%X: %w",
+		int32(f), utils.ErrInvalidParameter)
+}
+
+// transfer gets the pixel transfer format and type used when allocating
+// the texture storage without data.
+func (f Format) transfer() (format, xtype uint32) {
+	switch f {
+	case FormatDepth16, FormatDepth24:
+		return gl.DEPTH_COMPONENT, gl.UNSIGNED_INT
+	case FormatDepth32F:
+		return gl.DEPTH_COMPONENT, gl.FLOAT
+	case FormatDepth24Stencil8:
+		return gl.DEPTH_STENCIL, gl.UNSIGNED_INT_24_8
+	case FormatDepth32FStencil8:
+		return gl.DEPTH_STENCIL, gl.FLOAT_32_UNSIGNED_INT_24_8_REV
+	case FormatR8, FormatR16F, FormatR32F:
+		format = gl.RED
+	case FormatRG8, FormatRG16F, FormatRG32F:
+		format = gl.RG
+	case FormatRGB8, FormatSRGB8, FormatRGB16F, FormatRGB32F:
+		format = gl.RGB
+	default:
+		format = gl.RGBA
+	}
+	if f.IsFloat() {
+		return format, gl.FLOAT
+	}
+	return format, gl.UNSIGNED_BYTE
+}
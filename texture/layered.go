@@ -0,0 +1,329 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/STARRY-S/aperture/texture/codec"
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// layeredTexture is the texture with multiple images of the same size,
+// it is shared by TextureArrayObj and Texture3DObj, the target is set by
+// NewTextureArrayObj and NewTexture3DObj.
+type layeredTexture struct {
+	target   uint32
+	fileName string
+	id       uint32
+
+	width  int32
+	height int32
+	depth  int32
+	// format is the internal format of the loaded texture
+	format Format
+
+	// param is the sampling parameters used when loading the texture
+	param TextureParam
+}
+
+// TextureArrayObj is the 2D array texture (GL_TEXTURE_2D_ARRAY), each
+// layer is a same-sized image, it is sampled by sampler2DArray with the
+// layer index as the third texture coordinate.
+type TextureArrayObj struct {
+	layeredTexture
+}
+
+// Texture3DObj is the 3D texture (GL_TEXTURE_3D), each slice of the volume
+// is a same-sized image, it is sampled by sampler3D.
+type Texture3DObj struct {
+	layeredTexture
+}
+
+// Load loads one image file as the texture with only one layer (slice).
+func (t *layeredTexture) Load(f string) error {
+	if err := t.LoadFiles([]string{f}); err != nil {
+		return fmt.Errorf("Load: %w", err)
+	}
+	return nil
+}
+
+// LoadFiles loads the image files of the same size as the layers (slices)
+// in order, the HDR images are loaded as float textures.
+func (t *layeredTexture) LoadFiles(files []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("LoadFiles: %w", utils.ErrInvalidParameter)
+	}
+	p, err := t.param.normalize()
+	if err != nil {
+		return fmt.Errorf("LoadFiles: %w", err)
+	}
+	var bytes []byte
+	var floats []float32
+	var width, height int
+	for i, f := range files {
+		img, err := loadImage(f)
+		if err != nil {
+			return fmt.Errorf("LoadFiles: %w", err)
+		}
+		b := img.Bounds()
+		if i == 0 {
+			width, height = b.Dx(), b.Dy()
+		} else if b.Dx() != width || b.Dy() != height {
+			return fmt.Errorf("LoadFiles: size of image %q is not %dx%d: %w",
+				f, width, height, utils.ErrInvalidParameter)
+		}
+		switch img := img.(type) {
+		case *codec.RGBAF32:
+			floats = append(floats, img.Pix...)
+		case *image.RGBA:
+			bytes = append(bytes, img.Pix...)
+		}
+		if bytes != nil && floats != nil {
+			return fmt.Errorf("LoadFiles: HDR and LDR images are mixed: %w",
+				utils.ErrInvalidParameter)
+		}
+	}
+	var pixels interface{} = bytes
+	l := LayoutRGBA8
+	if floats != nil {
+		pixels, l = floats, LayoutRGBA32F
+	}
+	if err := t.upload(width, height, len(files), pixels, l, p); err != nil {
+		return fmt.Errorf("LoadFiles: %w", err)
+	}
+	t.fileName = files[0]
+	return nil
+}
+
+// LoadMemory loads the tightly packed RGBA8 layers (slices) from memory,
+// the layer count is the data size divided by the size of one layer.
+func (t *layeredTexture) LoadMemory(width, height int, data *[]byte) error {
+	if data == nil || width <= 0 || height <= 0 {
+		return fmt.Errorf("LoadMemory: %w", utils.ErrInvalidParameter)
+	}
+	depth := len(*data) / (width * height * 4)
+	if err := t.LoadMemoryLayout(width, height, depth, *data, LayoutRGBA8); err != nil {
+		return fmt.Errorf("LoadMemory: %w", err)
+	}
+	return nil
+}
+
+// LoadMemoryLayout loads the layers (slices) from the raw pixel data in
+// memory, the layers are stored one after another, the stride of the
+// layout is the row stride of each layer.
+func (t *layeredTexture) LoadMemoryLayout(width, height, depth int, data []byte, l PixelLayout) error {
+	if depth <= 0 {
+		return fmt.Errorf("LoadMemoryLayout: invalid depth %d: %w",
+			depth, utils.ErrInvalidParameter)
+	}
+	// validate as one image with all rows of all layers
+	if err := l.validate(width, height*depth, len(data)); err != nil {
+		return fmt.Errorf("LoadMemoryLayout: %w", err)
+	}
+	p, err := t.param.normalize()
+	if err != nil {
+		return fmt.Errorf("LoadMemoryLayout: %w", err)
+	}
+	data = l.pack(width, height*depth, data)
+	l.Stride = 0
+	if err := t.upload(width, height, depth, data, l, p); err != nil {
+		return fmt.Errorf("LoadMemoryLayout: %w", err)
+	}
+	return nil
+}
+
+// LoadRaw loads the raw volume file without header, the file contains the
+// tightly packed pixels of all layers (slices) in the layout.
+func (t *layeredTexture) LoadRaw(f string, width, height, depth int, l PixelLayout) error {
+	data, err := os.ReadFile(f)
+	if err != nil {
+		return fmt.Errorf("LoadRaw: %w", err)
+	}
+	if err := t.LoadMemoryLayout(width, height, depth, data, l); err != nil {
+		return fmt.Errorf("LoadRaw: failed to load texture from file %v: %w", f, err)
+	}
+	t.fileName = f
+	return nil
+}
+
+// Alloc allocates the texture storage of the internal format set in the
+// texture parameters without data. FormatAuto allocates RGBA8 storage, the
+// storage of all mipmap levels is allocated if Mipmap is set.
+func (t *layeredTexture) Alloc(width, height, depth int) error {
+	if width <= 0 || height <= 0 || depth <= 0 {
+		return fmt.Errorf("Alloc: %w", utils.ErrInvalidParameter)
+	}
+	p, err := t.param.normalize()
+	if err != nil {
+		return fmt.Errorf("Alloc: %w", err)
+	}
+	if t.target == 0 {
+		return fmt.Errorf("Alloc: texture target not set: %w", utils.ErrInvalidPointer)
+	}
+	if p.Format == FormatAuto {
+		p.Format = FormatRGBA8
+	}
+	if p.Format.IsDepth() && t.target == gl.TEXTURE_3D {
+		return fmt.Errorf("Alloc: 3D texture does not support depth format: %w",
+			utils.ErrInvalidParameter)
+	}
+	format, xtype := p.Format.transfer()
+	id := newLayeredTexture(t.target, width, height, depth, nil,
+		int32(p.Format), format, xtype, p)
+	if id == 0 {
+		return fmt.Errorf("failed to allocate texture")
+	}
+	t.Release()
+	t.id, t.width, t.height, t.depth, t.format =
+		id, int32(width), int32(height), int32(depth), p.Format
+	return nil
+}
+
+func (t *layeredTexture) upload(width, height, depth int, pixels interface{}, l PixelLayout, p TextureParam) error {
+	if t.target == 0 {
+		return fmt.Errorf("texture target not set: %w", utils.ErrInvalidPointer)
+	}
+	format := p.Format
+	if format == FormatAuto {
+		format = Format(l.glInternalFormat())
+	}
+	if format.IsDepth() {
+		return fmt.Errorf("depth format requires Alloc: %w", utils.ErrInvalidParameter)
+	}
+	id := newLayeredTexture(t.target, width, height, depth, pixels,
+		int32(format), l.glFormat(), l.glType(), p)
+	if id == 0 {
+		return fmt.Errorf("failed to create texture")
+	}
+	t.Release()
+	t.id, t.width, t.height, t.depth, t.format =
+		id, int32(width), int32(height), int32(depth), format
+	return nil
+}
+
+// GetSize gets the width, height and the layer (slice) count.
+func (t *layeredTexture) GetSize() (width, height, depth int32) {
+	return t.width, t.height, t.depth
+}
+
+// GetFormat gets the internal format of the loaded texture.
+func (t *layeredTexture) GetFormat() Format {
+	return t.format
+}
+
+// GetTarget gets the texture target, GL_TEXTURE_2D_ARRAY or GL_TEXTURE_3D.
+func (t *layeredTexture) GetTarget() uint32 {
+	return t.target
+}
+
+// SetParam sets the sampling parameters of the texture, the parameters
+// are applied to the loaded texture immediately, or applied when loading
+// if the texture is not loaded yet.
+func (t *layeredTexture) SetParam(p TextureParam) error {
+	np, err := p.normalize()
+	if err != nil {
+		return fmt.Errorf("SetParam: %w", err)
+	}
+	if t.id != 0 {
+		binding := uint32(gl.TEXTURE_BINDING_2D_ARRAY)
+		if t.target == gl.TEXTURE_3D {
+			binding = gl.TEXTURE_BINDING_3D
+		}
+		defer bindTexture(t.target, binding, t.id)()
+		if np.Mipmap && !t.param.Mipmap {
+			gl.GenerateMipmap(t.target)
+		}
+		applyTextureParam(t.target, np)
+	}
+	t.param = p
+	return nil
+}
+
+func (t *layeredTexture) GetParam() TextureParam {
+	return t.param
+}
+
+func (t *layeredTexture) GetID() uint32 {
+	return t.id
+}
+
+// Release deletes the OpenGL texture.
+func (t *layeredTexture) Release() {
+	if t.id == 0 {
+		return
+	}
+	gl.DeleteTextures(1, &t.id)
+	t.id = 0
+}
+
+func (t *layeredTexture) SetFileName(name string) {
+	t.fileName = name
+}
+
+func (t *layeredTexture) GetFileName() string {
+	return t.fileName
+}
+
+// newLayeredTexture uploads the tightly packed pixels of all layers to a
+// new array or 3D texture, pixels is nil to allocate storage only.
+func newLayeredTexture(target uint32, width, height, depth int, pixels interface{}, internal int32, format, xtype uint32, p TextureParam) uint32 {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(target, texture)
+	applyTextureParam(target, p)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexImage3D(
+		target,
+		0,
+		internal,
+		int32(width),
+		int32(height),
+		int32(depth),
+		0,
+		format,
+		xtype,
+		gl.Ptr(pixels))
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+	switch {
+	case p.Mipmap && pixels == nil:
+		// the layers of the array texture are not reduced
+		for level := int32(1); width > 1 || height > 1 ||
+			(target == gl.TEXTURE_3D && depth > 1); level++ {
+			width, height = mipSize(width), mipSize(height)
+			if target == gl.TEXTURE_3D {
+				depth = mipSize(depth)
+			}
+			gl.TexImage3D(target, level, internal, int32(width), int32(height),
+				int32(depth), 0, format, xtype, nil)
+		}
+	case p.Mipmap && !Format(internal).IsDepth():
+		gl.GenerateMipmap(target)
+	}
+
+	return texture
+}
+
+func NewTextureArrayObj(p *TextureParam) (*TextureArrayObj, error) {
+	t := TextureArrayObj{layeredTexture{target: gl.TEXTURE_2D_ARRAY}}
+	if p != nil {
+		if _, err := p.normalize(); err != nil {
+			return nil, fmt.Errorf("NewTextureArrayObj: %w", err)
+		}
+		t.param = *p
+	}
+	return &t, nil
+}
+
+func NewTexture3DObj(p *TextureParam) (*Texture3DObj, error) {
+	t := Texture3DObj{layeredTexture{target: gl.TEXTURE_3D}}
+	if p != nil {
+		if _, err := p.normalize(); err != nil {
+			return nil, fmt.Errorf("NewTexture3DObj: %w", err)
+		}
+		t.param = *p
+	}
+	return &t, nil
+}
@@ -0,0 +1,131 @@
+package texture
+
+import (
+	"fmt"
+
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// PixelType is the data type of each channel of the raw pixel data.
+type PixelType int
+
+const (
+	// PixelUint8 is the unsigned normalized 8 bits channel.
+	PixelUint8 PixelType = iota
+	// PixelFloat16 is the IEEE 754 half-precision float channel.
+	PixelFloat16
+	// PixelFloat32 is the IEEE 754 single-precision float channel.
+	PixelFloat32
+)
+
+// PixelLayout describes the layout of the raw pixel data in memory.
+type PixelLayout struct {
+	// Type is the data type of each channel.
+	Type PixelType
+	// Channels is the channel count of each pixel (1 to 4),
+	// the channels are in R, G, B, A order.
+	Channels int
+	// Stride is the bytes between vertically adjacent rows,
+	// 0 means the rows are tightly packed.
+	Stride int
+}
+
+// Predefined pixel layouts with tightly packed rows.
+var (
+	LayoutR8      = PixelLayout{Type: PixelUint8, Channels: 1}
+	LayoutRG8     = PixelLayout{Type: PixelUint8, Channels: 2}
+	LayoutRGB8    = PixelLayout{Type: PixelUint8, Channels: 3}
+	LayoutRGBA8   = PixelLayout{Type: PixelUint8, Channels: 4}
+	LayoutRGBA16F = PixelLayout{Type: PixelFloat16, Channels: 4}
+	LayoutRGBA32F = PixelLayout{Type: PixelFloat32, Channels: 4}
+)
+
+// WithStride gets the copy of the layout with the row stride in bytes.
+func (l PixelLayout) WithStride(stride int) PixelLayout {
+	l.Stride = stride
+	return l
+}
+
+// PixelSize gets the bytes of each pixel.
+func (l PixelLayout) PixelSize() int {
+	switch l.Type {
+	case PixelFloat16:
+		return 2 * l.Channels
+	case PixelFloat32:
+		return 4 * l.Channels
+	}
+	return l.Channels
+}
+
+// RowSize gets the bytes of each row, including the padding of stride.
+func (l PixelLayout) RowSize(width int) int {
+	if l.Stride > 0 {
+		return l.Stride
+	}
+	return width * l.PixelSize()
+}
+
+func (l PixelLayout) validate(width, height, size int) error {
+	if l.Channels < 1 || l.Channels > 4 {
+		return fmt.Errorf("invalid channel count %d: %w",
+			l.Channels, utils.ErrInvalidParameter)
+	}
+	if l.Type < PixelUint8 || l.Type > PixelFloat32 {
+		return fmt.Errorf("invalid pixel type %d: %w",
+			l.Type, utils.ErrInvalidParameter)
+	}
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid size %dx%d: %w",
+			width, height, utils.ErrInvalidParameter)
+	}
+	if l.Stride != 0 && l.Stride < width*l.PixelSize() {
+		return fmt.Errorf("stride %d less than row size %d: %w",
+			l.Stride, width*l.PixelSize(), utils.ErrInvalidParameter)
+	}
+	need := l.RowSize(width)*(height-1) + width*l.PixelSize()
+	if size < need {
+		return fmt.Errorf("data size %d less than %d: %w",
+			size, need, utils.ErrInvalidParameter)
+	}
+	return nil
+}
+
+// glFormat gets the pixel transfer format of the layout.
+func (l PixelLayout) glFormat() uint32 {
+	return [...]uint32{gl.RED, gl.RG, gl.RGB, gl.RGBA}[l.Channels-1]
+}
+
+// glType gets the pixel transfer data type of the layout.
+func (l PixelLayout) glType() uint32 {
+	switch l.Type {
+	case PixelFloat16:
+		return gl.HALF_FLOAT
+	case PixelFloat32:
+		return gl.FLOAT
+	}
+	return gl.UNSIGNED_BYTE
+}
+
+// glInternalFormat gets the sized internal format matching the layout.
+func (l PixelLayout) glInternalFormat() int32 {
+	formats := map[PixelType][4]int32{
+		PixelUint8:   {gl.R8, gl.RG8, gl.RGB8, gl.RGBA8},
+		PixelFloat16: {gl.R16F, gl.RG16F, gl.RGB16F, gl.RGBA16F},
+		PixelFloat32: {gl.R32F, gl.RG32F, gl.RGB32F, gl.RGBA32F},
+	}
+	return formats[l.Type][l.Channels-1]
+}
+
+// pack removes the padding of rows if stride is set.
+func (l PixelLayout) pack(width, height int, data []byte) []byte {
+	rowSize := width * l.PixelSize()
+	if l.Stride == 0 || l.Stride == rowSize {
+		return data
+	}
+	packed := make([]byte, rowSize*height)
+	for y := 0; y < height; y++ {
+		copy(packed[y*rowSize:(y+1)*rowSize], data[y*l.Stride:])
+	}
+	return packed
+}
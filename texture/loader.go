@@ -0,0 +1,335 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+	"time"
+
+	ap "github.com/STARRY-S/aperture"
+	"github.com/STARRY-S/aperture/utils"
+)
+
+// LoaderObj loads the texture files asynchronously, the images are
+// decoded by the worker goroutines and uploaded to OpenGL by Update on the
+// render thread within the time budget of each frame.
+type LoaderObj struct {
+	budget      time.Duration
+	placeholder ap.Texture
+	// defaultPlaceholder is uploaded by the first Update if the
+	// placeholder is not set in the init parameters
+	defaultPlaceholder *TextureObj
+
+	// workers limits the count of the decoding goroutines
+	workers chan struct{}
+	// closed is closed by Release to cancel the goroutines waiting for
+	// the workers
+	closed chan struct{}
+
+	mu sync.Mutex
+	// decoded is the futures decoded and waiting for uploading
+	decoded  []*FutureObj
+	total    int
+	finished int
+	released bool
+}
+
+// LoaderInitParam is used for customize the parameters when init loader.
+type LoaderInitParam struct {
+	// Workers is the count of the decoding goroutines,
+	// default is runtime.NumCPU().
+	Workers int
+	// Budget is the max time spent on uploading textures in each Update,
+	// at least one texture is uploaded in each Update. Default is 4ms.
+	Budget time.Duration
+	// Placeholder is the texture returned by the futures while loading,
+	// default is a 1x1 grey texture.
+	Placeholder ap.Texture
+}
+
+// FutureObj is the texture being loaded by the LoaderObj.
+type FutureObj struct {
+	loader   *LoaderObj
+	fileName string
+	param    TextureParam
+
+	img     image.Image
+	texture *TextureObj
+	err     error
+	done    bool
+}
+
+const (
+	defaultLoaderBudget = 4 * time.Millisecond
+)
+
+// errLoaderReleased finishes the futures still loading when the loader is
+// released.
+var errLoaderReleased = fmt.Errorf("Load: loader released: %w", utils.ErrInvalidPointer)
+
+func (l *LoaderObj) Init(initParam interface{}) error {
+	if l == nil {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidPointer)
+	}
+
+	if initParam == nil {
+		initParam = LoaderInitParam{}
+	}
+	p, ok := initParam.(LoaderInitParam)
+	if !ok {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidDataType)
+	}
+
+	if l.workers != nil {
+		return fmt.Errorf("Init: %w", utils.ErrReInitialize)
+	}
+
+	if p.Workers <= 0 {
+		p.Workers = runtime.NumCPU()
+	}
+	if p.Budget <= 0 {
+		p.Budget = defaultLoaderBudget
+	}
+	l.workers = make(chan struct{}, p.Workers)
+	l.closed = make(chan struct{})
+	l.budget = p.Budget
+	l.placeholder = p.Placeholder
+	if l.placeholder == nil {
+		l.defaultPlaceholder = &TextureObj{}
+		l.placeholder = l.defaultPlaceholder
+	}
+	return nil
+}
+
+// Load starts loading the texture file with the parameters (nil uses the
+// default parameters) and returns the future immediately.
+func (l *LoaderObj) Load(file string, p *TextureParam) *FutureObj {
+	f := &FutureObj{
+		loader:   l,
+		fileName: file,
+	}
+	if p != nil {
+		f.param = *p
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total++
+	if l.workers == nil {
+		f.finish(fmt.Errorf("Load: loader not initialized: %w", utils.ErrInvalidPointer))
+		l.finished++
+		return f
+	}
+	if l.released {
+		f.finish(errLoaderReleased)
+		l.finished++
+		return f
+	}
+	if _, err := f.param.normalize(); err != nil {
+		f.finish(fmt.Errorf("Load: %w", err))
+		l.finished++
+		return f
+	}
+
+	go l.decode(f)
+	return f
+}
+
+// decode decodes the image in the worker goroutine.
+func (l *LoaderObj) decode(f *FutureObj) {
+	select {
+	case l.workers <- struct{}{}:
+	case <-l.closed:
+		l.cancel(f)
+		return
+	}
+	// the loader may be released while waiting for the worker
+	select {
+	case <-l.closed:
+		<-l.workers
+		l.cancel(f)
+		return
+	default:
+	}
+	img, err := loadImage(f.fileName)
+	<-l.workers
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.released {
+		f.finish(errLoaderReleased)
+		l.finished++
+		return
+	}
+	if err != nil {
+		f.finish(fmt.Errorf("Load: %w", err))
+		l.finished++
+		return
+	}
+	f.img = img
+	l.decoded = append(l.decoded, f)
+}
+
+// Update uploads the decoded images to textures until the time budget is
+// exceeded, it should be called on the render thread in each frame.
+// It returns the count of the textures uploaded.
+func (l *LoaderObj) Update() int {
+	l.mu.Lock()
+	released := l.released
+	l.mu.Unlock()
+	if released {
+		return 0
+	}
+	if l.defaultPlaceholder != nil && l.defaultPlaceholder.GetID() == 0 {
+		grey := []byte{128, 128, 128, 255}
+		l.defaultPlaceholder.LoadMemory(1, 1, &grey)
+	}
+
+	start := time.Now()
+	count := 0
+	for count == 0 || time.Since(start) < l.budget {
+		l.mu.Lock()
+		if len(l.decoded) == 0 {
+			l.mu.Unlock()
+			break
+		}
+		f := l.decoded[0]
+		l.decoded = l.decoded[1:]
+		l.mu.Unlock()
+
+		// the param is validated by Load
+		p, _ := f.param.normalize()
+		t := &TextureObj{param: f.param}
+		err := t.uploadImage(f.img, p)
+		if err == nil {
+			t.fileName = f.fileName
+		} else {
+			err = fmt.Errorf("Load: failed to load texture from file %v: %w",
+				f.fileName, err)
+		}
+
+		l.mu.Lock()
+		if err == nil {
+			f.texture = t
+		}
+		f.img = nil
+		f.finish(err)
+		l.finished++
+		l.mu.Unlock()
+		count++
+	}
+	return count
+}
+
+// GetProgress gets the progress of all textures loaded by the loader,
+// from 0 to 1, it is 1 if no texture is loading.
+func (l *LoaderObj) GetProgress() float32 {
+	finished, total := l.GetCount()
+	if total == 0 {
+		return 1
+	}
+	return float32(finished) / float32(total)
+}
+
+// GetCount gets the count of the finished (loaded or failed) textures and
+// the count of all textures loaded by the loader.
+func (l *LoaderObj) GetCount() (finished, total int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.finished, l.total
+}
+
+// IsDone reports whether all textures loaded by the loader are finished.
+func (l *LoaderObj) IsDone() bool {
+	finished, total := l.GetCount()
+	return finished == total
+}
+
+func (l *LoaderObj) GetPlaceholder() ap.Texture {
+	return l.placeholder
+}
+
+// cancel finishes the future not decoded after the loader released.
+func (l *LoaderObj) cancel(f *FutureObj) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f.finish(errLoaderReleased)
+	l.finished++
+}
+
+// Release stops decoding and uploading the textures and releases the
+// default placeholder, the futures not uploaded yet are finished with the
+// error, including the images still being decoded.
+func (l *LoaderObj) Release() {
+	l.mu.Lock()
+	if !l.released && l.closed != nil {
+		close(l.closed)
+	}
+	l.released = true
+	for _, f := range l.decoded {
+		f.img = nil
+		f.finish(errLoaderReleased)
+		l.finished++
+	}
+	l.decoded = nil
+	l.mu.Unlock()
+
+	if l.defaultPlaceholder != nil {
+		l.defaultPlaceholder.Release()
+	}
+}
+
+// finish marks the future finished, the loader mutex must be held.
+func (f *FutureObj) finish(err error) {
+	f.err = err
+	f.done = true
+}
+
+// GetTexture gets the loaded texture, or the placeholder texture of the
+// loader if the texture is loading or failed to load.
+func (f *FutureObj) GetTexture() ap.Texture {
+	f.loader.mu.Lock()
+	defer f.loader.mu.Unlock()
+	if f.texture != nil {
+		return f.texture
+	}
+	return f.loader.placeholder
+}
+
+// GetTextureObj gets the loaded texture, it returns nil if the texture is
+// not loaded.
+func (f *FutureObj) GetTextureObj() *TextureObj {
+	f.loader.mu.Lock()
+	defer f.loader.mu.Unlock()
+	return f.texture
+}
+
+// IsDone reports whether the texture is loaded or failed to load.
+func (f *FutureObj) IsDone() bool {
+	f.loader.mu.Lock()
+	defer f.loader.mu.Unlock()
+	return f.done
+}
+
+// GetError gets the error of loading the texture.
+func (f *FutureObj) GetError() error {
+	f.loader.mu.Lock()
+	defer f.loader.mu.Unlock()
+	return f.err
+}
+
+func (f *FutureObj) GetFileName() string {
+	return f.fileName
+}
+
+func NewLoaderObj(p *LoaderInitParam) (*LoaderObj, error) {
+	l := LoaderObj{}
+	if p == nil {
+		p = &LoaderInitParam{}
+	}
+	if err := l.Init(*p); err != nil {
+		return nil, fmt.Errorf("NewLoaderObj: %w", err)
+	}
+	return &l, nil
+}
@@ -0,0 +1,211 @@
+package texture
+
+import (
+	"fmt"
+
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// TextureParam is used for customize the sampling parameters of the
+// texture and sampler objects, the zero value fields use the default value.
+type TextureParam struct {
+	// MinFilter is the GL_TEXTURE_MIN_FILTER, default is gl.LINEAR,
+	// the mipmap filters (e.g. gl.LINEAR_MIPMAP_LINEAR) of the textures
+	// require Mipmap.
+	MinFilter int32
+	// MagFilter is the GL_TEXTURE_MAG_FILTER, default is gl.LINEAR.
+	MagFilter int32
+
+	// WrapS, WrapT and WrapR are the wrap modes of each axis,
+	// default is gl.CLAMP_TO_EDGE.
+	WrapS int32
+	WrapT int32
+	WrapR int32
+
+	// BorderColor is the RGBA border color used by gl.CLAMP_TO_BORDER.
+	BorderColor [4]float32
+
+	// Mipmap generates the mipmaps after the image uploaded, it is ignored
+	// by the sampler objects.
+	Mipmap bool
+
+	// Anisotropy is the max anisotropic filtering level, it is clamped to
+	// the max level supported by the driver, 0 or 1 disables it.
+	Anisotropy float32
+
+	// LODBias is the GL_TEXTURE_LOD_BIAS.
+	LODBias float32
+
+	// CompareFunc enables the depth comparison (GL_COMPARE_REF_TO_TEXTURE)
+	// of depth textures sampled by sampler2DShadow, e.g. gl.LEQUAL,
+	// 0 disables the depth comparison.
+	CompareFunc int32
+
+	// Format is the internal format of the texture, it is ignored by
+	// the sampler objects. Default is FormatAuto.
+	Format Format
+}
+
+const (
+	defaultTextureFilter = gl.LINEAR
+	defaultTextureWrap   = gl.CLAMP_TO_EDGE
+)
+
+// DefaultTextureParam gets the default texture parameters.
+func DefaultTextureParam() TextureParam {
+	return TextureParam{
+		MinFilter: defaultTextureFilter,
+		MagFilter: defaultTextureFilter,
+		WrapS:     defaultTextureWrap,
+		WrapT:     defaultTextureWrap,
+		WrapR:     defaultTextureWrap,
+	}
+}
+
+// normalize sets the default values of the zero value fields and
+// checks the parameters of the textures are valid.
+func (p TextureParam) normalize() (TextureParam, error) {
+	p, err := p.normalizeSampler()
+	if err != nil {
+		return p, err
+	}
+	switch p.MinFilter {
+	case gl.NEAREST_MIPMAP_NEAREST, gl.LINEAR_MIPMAP_NEAREST,
+		gl.NEAREST_MIPMAP_LINEAR, gl.LINEAR_MIPMAP_LINEAR:
+		if !p.Mipmap {
+			return p, fmt.Errorf("mipmap min filter requires Mipmap: %w",
+				utils.ErrInvalidParameter)
+		}
+	}
+	return p, nil
+}
+
+// normalizeSampler sets the default values of the zero value fields and
+// checks the parameters of the sampler objects are valid, the mipmap min
+// filters do not require Mipmap.
+func (p TextureParam) normalizeSampler() (TextureParam, error) {
+	if p.MinFilter == 0 {
+		p.MinFilter = defaultTextureFilter
+	}
+	if p.MagFilter == 0 {
+		p.MagFilter = defaultTextureFilter
+	}
+	if p.WrapS == 0 {
+		p.WrapS = defaultTextureWrap
+	}
+	if p.WrapT == 0 {
+		p.WrapT = defaultTextureWrap
+	}
+	if p.WrapR == 0 {
+		p.WrapR = defaultTextureWrap
+	}
+
+	switch p.MinFilter {
+	case gl.NEAREST, gl.LINEAR,
+		gl.NEAREST_MIPMAP_NEAREST, gl.LINEAR_MIPMAP_NEAREST,
+		gl.NEAREST_MIPMAP_LINEAR, gl.LINEAR_MIPMAP_LINEAR:
+	default:
+		return p, fmt.Errorf("invalid min filter 0x%X: %w",
+			p.MinFilter, utils.ErrInvalidParameter)
+	}
+	if p.MagFilter != gl.NEAREST && p.MagFilter != gl.LINEAR {
+		return p, fmt.Errorf("invalid mag filter 0x%X: %w",
+			p.MagFilter, utils.ErrInvalidParameter)
+	}
+	for _, w := range []int32{p.WrapS, p.WrapT, p.WrapR} {
+		switch w {
+		case gl.REPEAT, gl.MIRRORED_REPEAT, gl.CLAMP_TO_EDGE, gl.CLAMP_TO_BORDER:
+		default:
+			return p, fmt.Errorf("invalid wrap mode 0x%X: %w",
+				w, utils.ErrInvalidParameter)
+		}
+	}
+	switch p.CompareFunc {
+	case 0, gl.NEVER, gl.LESS, gl.EQUAL, gl.LEQUAL,
+		gl.GREATER, gl.NOTEQUAL, gl.GEQUAL, gl.ALWAYS:
+	default:
+		return p, fmt.Errorf("invalid compare func 0x%X: %w",
+			p.CompareFunc, utils.ErrInvalidParameter)
+	}
+	if err := p.Format.validate(); err != nil {
+		return p, err
+	}
+	if p.Anisotropy < 0 {
+		return p, fmt.Errorf("invalid anisotropy %v: %w",
+			p.Anisotropy, utils.ErrInvalidParameter)
+	}
+	return p, nil
+}
+
+// maxAnisotropy gets the max anisotropic filtering level supported by the
+// driver, it returns 0 if anisotropic filtering is not supported.
+func maxAnisotropy() float32 {
+	var max float32
+	gl.GetFloatv(gl.MAX_TEXTURE_MAX_ANISOTROPY, &max)
+	// clear the GL_INVALID_ENUM error if not supported
+	gl.GetError()
+	return max
+}
+
+// bindTexture binds the texture to the target of the active texture unit,
+// call the returned function to restore the previous binding of the target,
+// binding is the query of the target, e.g. gl.TEXTURE_BINDING_2D.
+func bindTexture(target, binding, id uint32) func() {
+	var prev int32
+	gl.GetIntegerv(binding, &prev)
+	gl.BindTexture(target, id)
+	return func() {
+		gl.BindTexture(target, uint32(prev))
+	}
+}
+
+// applyTextureParam applies the parameters to the texture bound to target.
+func applyTextureParam(target uint32, p TextureParam) {
+	gl.TexParameteri(target, gl.TEXTURE_MIN_FILTER, p.MinFilter)
+	gl.TexParameteri(target, gl.TEXTURE_MAG_FILTER, p.MagFilter)
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_S, p.WrapS)
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_T, p.WrapT)
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_R, p.WrapR)
+	gl.TexParameterfv(target, gl.TEXTURE_BORDER_COLOR, &p.BorderColor[0])
+	gl.TexParameterf(target, gl.TEXTURE_LOD_BIAS, p.LODBias)
+	if p.CompareFunc != 0 {
+		gl.TexParameteri(target, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+		gl.TexParameteri(target, gl.TEXTURE_COMPARE_FUNC, p.CompareFunc)
+	} else {
+		gl.TexParameteri(target, gl.TEXTURE_COMPARE_MODE, gl.NONE)
+	}
+	if p.Anisotropy > 1 {
+		if max := maxAnisotropy(); max > 0 {
+			if p.Anisotropy > max {
+				p.Anisotropy = max
+			}
+			gl.TexParameterf(target, gl.TEXTURE_MAX_ANISOTROPY, p.Anisotropy)
+		}
+	}
+}
+
+// applySamplerParam applies the parameters to the sampler object.
+func applySamplerParam(sampler uint32, p TextureParam) {
+	gl.SamplerParameteri(sampler, gl.TEXTURE_MIN_FILTER, p.MinFilter)
+	gl.SamplerParameteri(sampler, gl.TEXTURE_MAG_FILTER, p.MagFilter)
+	gl.SamplerParameteri(sampler, gl.TEXTURE_WRAP_S, p.WrapS)
+	gl.SamplerParameteri(sampler, gl.TEXTURE_WRAP_T, p.WrapT)
+	gl.SamplerParameteri(sampler, gl.TEXTURE_WRAP_R, p.WrapR)
+	gl.SamplerParameterfv(sampler, gl.TEXTURE_BORDER_COLOR, &p.BorderColor[0])
+	gl.SamplerParameterf(sampler, gl.TEXTURE_LOD_BIAS, p.LODBias)
+	if p.CompareFunc != 0 {
+		gl.SamplerParameteri(sampler, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+		gl.SamplerParameteri(sampler, gl.TEXTURE_COMPARE_FUNC, p.CompareFunc)
+	} else {
+		gl.SamplerParameteri(sampler, gl.TEXTURE_COMPARE_MODE, gl.NONE)
+	}
+	if p.Anisotropy > 1 {
+		if max := maxAnisotropy(); max > 0 {
+			if p.Anisotropy > max {
+				p.Anisotropy = max
+			}
+			gl.SamplerParameterf(sampler, gl.TEXTURE_MAX_ANISOTROPY, p.Anisotropy)
+		}
+	}
+}
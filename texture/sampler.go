@@ -0,0 +1,91 @@
+package texture
+
+import (
+	"fmt"
+
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// SamplerObj is the OpenGL sampler object, the sampler bound to a texture
+// unit overrides the sampling parameters of the texture bound to the unit,
+// so one texture can be sampled differently by different shaders.
+type SamplerObj struct {
+	param TextureParam
+	id    uint32
+}
+
+// Init creates the sampler object with the parameters,
+// this method requires an active OpenGL context.
+func (s *SamplerObj) Init(p TextureParam) error {
+	if s == nil {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidPointer)
+	}
+	if s.id != 0 {
+		return fmt.Errorf("Init: %w", utils.ErrReInitialize)
+	}
+	p, err := p.normalizeSampler()
+	if err != nil {
+		return fmt.Errorf("Init: %w", err)
+	}
+	gl.GenSamplers(1, &s.id)
+	if s.id == 0 {
+		return fmt.Errorf("failed to create sampler object")
+	}
+	s.param = p
+	applySamplerParam(s.id, p)
+	return nil
+}
+
+// SetParam updates the parameters of the sampler object.
+func (s *SamplerObj) SetParam(p TextureParam) error {
+	p, err := p.normalizeSampler()
+	if err != nil {
+		return fmt.Errorf("SetParam: %w", err)
+	}
+	s.param = p
+	if s.id != 0 {
+		applySamplerParam(s.id, p)
+	}
+	return nil
+}
+
+func (s *SamplerObj) GetParam() TextureParam {
+	return s.param
+}
+
+// Bind binds the sampler to the texture unit (0 for GL_TEXTURE0).
+func (s *SamplerObj) Bind(unit uint32) {
+	gl.BindSampler(unit, s.id)
+}
+
+// Unbind unbinds the sampler of the texture unit, the texture bound to the
+// unit uses its own parameters again.
+func (s *SamplerObj) Unbind(unit uint32) {
+	gl.BindSampler(unit, 0)
+}
+
+func (s *SamplerObj) GetID() uint32 {
+	return s.id
+}
+
+// Release deletes the sampler object.
+func (s *SamplerObj) Release() {
+	if s.id == 0 {
+		return
+	}
+	gl.DeleteSamplers(1, &s.id)
+	s.id = 0
+}
+
+func NewSamplerObj(p *TextureParam) (*SamplerObj, error) {
+	s := SamplerObj{}
+	if p == nil {
+		p = &TextureParam{}
+	}
+	err := s.Init(*p)
+	if err != nil {
+		return nil, fmt.Errorf("NewSamplerObj: %w", err)
+	}
+	return &s, nil
+}
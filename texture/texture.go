@@ -8,6 +8,7 @@ import (
 	_ "image/png"
 	"os"
 
+	"github.com/STARRY-S/aperture/texture/codec"
 	"github.com/STARRY-S/aperture/utils"
 	"github.com/go-gl/gl/v3.3-core/gl"
 )
@@ -16,39 +17,181 @@ type TextureObj struct {
 	fileName string
 	rgba     [4]float32
 	id       uint32
+
+	width  int32
+	height int32
+	// format is the internal format of the loaded texture
+	format Format
+
+	// param is the sampling parameters used when loading the texture
+	param TextureParam
+
+	// cache is the texture cache which loaded the texture, the texture
+	// is deleted by the cache when the last reference released
+	cache *CacheObj
 }
 
-// Load texture image from file
+// Load texture image from file, the supported formats are JPEG, PNG, BMP,
+// TGA and Radiance HDR, the HDR images are loaded as float textures.
 func (t *TextureObj) Load(f string) error {
-	rgba, err := loadImage(f)
+	p, err := t.param.normalize()
 	if err != nil {
 		return fmt.Errorf("Load: %w", err)
 	}
-	id := newTextureRGBA(rgba)
-	if id == 0 {
-		return fmt.Errorf("failed to load texture from file %v", f)
+	img, err := loadImage(f)
+	if err != nil {
+		return fmt.Errorf("Load: %w", err)
+	}
+	if err := t.uploadImage(img, p); err != nil {
+		return fmt.Errorf("Load: failed to load texture from file %v: %w", f, err)
 	}
-	t.id = id
 	t.fileName = f
 	return nil
 }
 
-// Load texture image from memory
+// uploadImage uploads the image decoded by loadImage.
+func (t *TextureObj) uploadImage(img image.Image, p TextureParam) error {
+	var pixels interface{}
+	var l PixelLayout
+	switch img := img.(type) {
+	case *codec.RGBAF32:
+		pixels, l = img.Pix, LayoutRGBA32F
+	case *image.RGBA:
+		pixels, l = img.Pix, LayoutRGBA8
+	default:
+		return fmt.Errorf("unsupported image type %T: %w", img, utils.ErrInvalidDataType)
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	return t.upload(width, height, pixels, l, p)
+}
+
+// Load texture image from memory, the data is tightly packed RGBA8 pixels.
 func (t *TextureObj) LoadMemory(width, height int, data *[]byte) error {
-	if width <= 0 || height <= 0 {
+	if data == nil {
 		return utils.ErrInvalidParameter
 	}
-	id := newTextureMemory(width, height, data)
-	if id != 0 {
-		return fmt.Errorf("failed to load texture from memory")
+	return t.LoadMemoryLayout(width, height, *data, LayoutRGBA8)
+}
+
+// LoadMemoryLayout loads the texture image from raw pixel data in memory,
+// the layout describes the channel type, channel count and row stride of
+// the data, e.g. LayoutRGB8.WithStride(stride).
+func (t *TextureObj) LoadMemoryLayout(width, height int, data []byte, l PixelLayout) error {
+	if err := l.validate(width, height, len(data)); err != nil {
+		return fmt.Errorf("LoadMemoryLayout: %w", err)
+	}
+	p, err := t.param.normalize()
+	if err != nil {
+		return fmt.Errorf("LoadMemoryLayout: %w", err)
+	}
+	data = l.pack(width, height, data)
+	l.Stride = 0
+	if err := t.upload(width, height, data, l, p); err != nil {
+		return fmt.Errorf("LoadMemoryLayout: %w", err)
 	}
 	return nil
 }
 
+// Alloc allocates the texture storage of the internal format set in the
+// texture parameters without data, it is used to create the render targets
+// and shadow maps. FormatAuto allocates RGBA8 storage, the storage of all
+// mipmap levels is allocated if Mipmap is set.
+func (t *TextureObj) Alloc(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("Alloc: %w", utils.ErrInvalidParameter)
+	}
+	p, err := t.param.normalize()
+	if err != nil {
+		return fmt.Errorf("Alloc: %w", err)
+	}
+	if p.Format == FormatAuto {
+		p.Format = FormatRGBA8
+	}
+	format, xtype := p.Format.transfer()
+	id := newTexture(width, height, nil, int32(p.Format), format, xtype, p)
+	if id == 0 {
+		return fmt.Errorf("failed to allocate texture")
+	}
+	t.delete()
+	t.id, t.width, t.height, t.format = id, int32(width), int32(height), p.Format
+	return nil
+}
+
+// upload uploads the pixels to a new texture with the internal format in
+// the parameters or the format matching the layout.
+func (t *TextureObj) upload(width, height int, pixels interface{}, l PixelLayout, p TextureParam) error {
+	format := p.Format
+	if format == FormatAuto {
+		format = Format(l.glInternalFormat())
+	}
+	if format.IsDepth() {
+		return fmt.Errorf("depth format requires Alloc: %w", utils.ErrInvalidParameter)
+	}
+	id := newTexture(width, height, pixels, int32(format), l.glFormat(), l.glType(), p)
+	if id == 0 {
+		return fmt.Errorf("failed to create texture")
+	}
+	t.delete()
+	t.id, t.width, t.height, t.format = id, int32(width), int32(height), format
+	return nil
+}
+
+// GetSize gets the width and height of the texture.
+func (t *TextureObj) GetSize() (width, height int32) {
+	return t.width, t.height
+}
+
+// GetFormat gets the internal format of the loaded texture.
+func (t *TextureObj) GetFormat() Format {
+	return t.format
+}
+
+// SetParam sets the sampling parameters of the texture, the parameters
+// are applied to the loaded texture immediately, or applied when loading
+// if the texture is not loaded yet. The internal format of the loaded
+// texture is not changed until the texture is loaded again.
+func (t *TextureObj) SetParam(p TextureParam) error {
+	np, err := p.normalize()
+	if err != nil {
+		return fmt.Errorf("SetParam: %w", err)
+	}
+	if t.id != 0 {
+		defer bindTexture(gl.TEXTURE_2D, gl.TEXTURE_BINDING_2D, t.id)()
+		if np.Mipmap && !t.param.Mipmap {
+			gl.GenerateMipmap(gl.TEXTURE_2D)
+		}
+		applyTextureParam(gl.TEXTURE_2D, np)
+	}
+	t.param = p
+	return nil
+}
+
+func (t *TextureObj) GetParam() TextureParam {
+	return t.param
+}
+
 func (t TextureObj) GetID() uint32 {
 	return t.id
 }
 
+// Release deletes the OpenGL texture, if the texture is loaded by the
+// texture cache, it releases one reference of the cache instead.
+func (t *TextureObj) Release() {
+	if t.cache != nil {
+		t.cache.release(t)
+		return
+	}
+	t.delete()
+}
+
+func (t *TextureObj) delete() {
+	if t.id == 0 {
+		return
+	}
+	gl.DeleteTextures(1, &t.id)
+	t.id = 0
+}
+
 func (t *TextureObj) SetFileName(name string) {
 	t.fileName = name
 }
@@ -57,50 +200,83 @@ func (t *TextureObj) GetFileName() string {
 	return t.fileName
 }
 
-func loadImage(file string) (*image.RGBA, error) {
+// loadImage decodes the image file, the HDR images are decoded as
+// *codec.RGBAF32, others are converted to *image.RGBA.
+func loadImage(file string) (image.Image, error) {
 	imgFile, err := os.Open(file)
-	defer imgFile.Close()
 	if err != nil {
 		return nil, fmt.Errorf("image %q not found on disk:\n%v", file, err)
 	}
-	imgFile.Seek(0, 0)
+	defer imgFile.Close()
 	img, _, err := image.Decode(imgFile)
 	if err != nil {
 		return nil, err
 	}
+	if f, ok := img.(*codec.RGBAF32); ok {
+		return f, nil
+	}
 
 	rgba := image.NewRGBA(img.Bounds())
 	if rgba.Stride != rgba.Rect.Size().X*4 {
 		return nil, fmt.Errorf("unsupported stride")
 	}
-	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
 
 	return rgba, nil
 }
 
-func newTextureRGBA(rgba *image.RGBA) uint32 {
-	return newTextureMemory(rgba.Rect.Size().X, rgba.Rect.Size().X, &rgba.Pix)
-}
-
-func newTextureMemory(width, height int, data *[]byte) uint32 {
+// newTexture uploads the tightly packed pixels to a new 2D texture,
+// pixels is a slice of the pixel data or nil to allocate storage only.
+func newTexture(width, height int, pixels interface{}, internal int32, format, xtype uint32, p TextureParam) uint32 {
 	var texture uint32
 	gl.GenTextures(1, &texture)
 	gl.ActiveTexture(gl.TEXTURE0)
 	gl.BindTexture(gl.TEXTURE_2D, texture)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	applyTextureParam(gl.TEXTURE_2D, p)
+	// rows of R8, RG8 and RGB8 data are not 4 bytes aligned
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
 	gl.TexImage2D(
 		gl.TEXTURE_2D,
 		0,
-		gl.RGBA,
+		internal,
 		int32(width),
 		int32(height),
 		0,
-		gl.RGBA,
-		gl.UNSIGNED_BYTE,
-		gl.Ptr(*data))
+		format,
+		xtype,
+		gl.Ptr(pixels))
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+	switch {
+	case p.Mipmap && pixels == nil:
+		// the texture with the mipmap min filter is incomplete without
+		// the storage of all levels
+		for level := int32(1); width > 1 || height > 1; level++ {
+			width, height = mipSize(width), mipSize(height)
+			gl.TexImage2D(gl.TEXTURE_2D, level, internal,
+				int32(width), int32(height), 0, format, xtype, nil)
+		}
+	case p.Mipmap && !Format(internal).IsDepth():
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	}
 
 	return texture
 }
+
+// mipSize gets the size of the next mipmap level.
+func mipSize(size int) int {
+	if size > 1 {
+		return size / 2
+	}
+	return 1
+}
+
+func NewTextureObj(p *TextureParam) (*TextureObj, error) {
+	t := TextureObj{}
+	if p != nil {
+		if _, err := p.normalize(); err != nil {
+			return nil, fmt.Errorf("NewTextureObj: %w", err)
+		}
+		t.param = *p
+	}
+	return &t, nil
+}
@@ -1,12 +1,18 @@
 package texture_test
 
 import (
+	"image"
+	"image/color"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/STARRY-S/aperture"
 	"github.com/STARRY-S/aperture/renderer"
 	"github.com/STARRY-S/aperture/texture"
+	"github.com/STARRY-S/aperture/texture/codec"
 	"github.com/STARRY-S/aperture/window"
+	"github.com/go-gl/gl/v3.3-core/gl"
 )
 
 const (
@@ -23,6 +29,45 @@ func TestInterface(t *testing.T) {
 	}
 }
 
+func TestParam(t *testing.T) {
+	_, err := texture.NewTextureObj(&texture.TextureParam{
+		MinFilter: gl.LINEAR_MIPMAP_LINEAR,
+	})
+	if err == nil {
+		t.Errorf("mipmap filter without mipmap should return error")
+	}
+	_, err = texture.NewTextureObj(&texture.TextureParam{
+		WrapS: gl.LINEAR,
+	})
+	if err == nil {
+		t.Errorf("invalid wrap mode should return error")
+	}
+	tex, err := texture.NewTextureObj(&texture.TextureParam{
+		MinFilter:  gl.LINEAR_MIPMAP_LINEAR,
+		WrapS:      gl.REPEAT,
+		Mipmap:     true,
+		Anisotropy: 16,
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if tex.GetParam().WrapS != gl.REPEAT {
+		t.Errorf("WrapS should be gl.REPEAT")
+	}
+	_, err = texture.NewTextureObj(&texture.TextureParam{
+		Format: texture.Format(gl.RGBA),
+	})
+	if err == nil {
+		t.Errorf("unsized internal format should return error")
+	}
+	if !texture.FormatDepth24Stencil8.IsDepth() || !texture.FormatDepth24Stencil8.IsStencil() {
+		t.Errorf("FormatDepth24Stencil8 should be depth-stencil format")
+	}
+	if !texture.FormatSRGB8Alpha8.IsSRGB() || !texture.FormatRGBA16F.IsFloat() {
+		t.Errorf("invalid format type")
+	}
+}
+
 func TestLoadJPG(t *testing.T) {
 	renderer.InitAll()
 	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
@@ -95,3 +140,577 @@ func TestLoadPNG(t *testing.T) {
 	r.Release()
 	renderer.TerminateAll()
 }
+
+func TestLoadParamSampler(t *testing.T) {
+	renderer.InitAll()
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf("failed to init renderer")
+	}
+	win, err := window.NewWindowObj(&window.WindowInitParam{})
+	r.AppendWindow(win)
+
+	tex, err := texture.NewTextureObj(&texture.TextureParam{
+		MinFilter:   gl.LINEAR_MIPMAP_LINEAR,
+		WrapS:       gl.REPEAT,
+		WrapT:       gl.CLAMP_TO_BORDER,
+		BorderColor: [4]float32{1, 0, 0, 1},
+		Mipmap:      true,
+		Anisotropy:  8,
+		LODBias:     -0.5,
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := tex.Load(TestTexturePNG); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if tex.GetID() == 0 {
+		t.Errorf("texture id is 0")
+	}
+	if err := tex.SetParam(texture.TextureParam{MinFilter: gl.NEAREST}); err != nil {
+		t.Errorf(err.Error())
+	}
+
+	data := make([]byte, 2*2*4)
+	mem := texture.TextureObj{}
+	if err := mem.LoadMemory(2, 2, &data); err != nil {
+		t.Errorf(err.Error())
+	}
+	if mem.GetID() == 0 {
+		t.Errorf("texture id is 0")
+	}
+
+	// SetParam keeps the texture bound to the active unit
+	var bound int32
+	gl.BindTexture(gl.TEXTURE_2D, mem.GetID())
+	if err := tex.SetParam(texture.TextureParam{MinFilter: gl.LINEAR}); err != nil {
+		t.Errorf(err.Error())
+	}
+	gl.GetIntegerv(gl.TEXTURE_BINDING_2D, &bound)
+	if uint32(bound) != mem.GetID() {
+		t.Errorf("SetParam should restore the texture binding")
+	}
+
+	sampler, err := texture.NewSamplerObj(&texture.TextureParam{
+		MinFilter: gl.NEAREST,
+		MagFilter: gl.NEAREST,
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if sampler.GetID() == 0 {
+		t.Errorf("sampler id is 0")
+	}
+	sampler.Bind(0)
+	sampler.Unbind(0)
+	sampler.Release()
+
+	// the mipmap min filter of the sampler does not require Mipmap
+	sampler, err = texture.NewSamplerObj(&texture.TextureParam{
+		MinFilter: gl.LINEAR_MIPMAP_LINEAR,
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	sampler.Release()
+	sampler, err = texture.NewSamplerObj(nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	sampler.Release()
+
+	r.Release()
+	renderer.TerminateAll()
+}
+
+func TestLoadMemoryLayout(t *testing.T) {
+	tex := texture.TextureObj{}
+	data := make([]byte, 3*2)
+	// stride less than row size
+	err := tex.LoadMemoryLayout(2, 2, data, texture.LayoutRGB8.WithStride(4))
+	if err == nil {
+		t.Errorf("invalid stride should return error")
+	}
+	err = tex.LoadMemoryLayout(2, 2, data, texture.PixelLayout{Channels: 5})
+	if err == nil {
+		t.Errorf("invalid channel count should return error")
+	}
+
+	renderer.InitAll()
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf("failed to init renderer")
+	}
+	win, err := window.NewWindowObj(&window.WindowInitParam{})
+	r.AppendWindow(win)
+
+	for _, l := range []texture.PixelLayout{
+		texture.LayoutR8,
+		texture.LayoutRG8,
+		texture.LayoutRGB8.WithStride(8),
+		texture.LayoutRGBA16F,
+		texture.LayoutRGBA32F,
+	} {
+		data := make([]byte, l.RowSize(2)*2)
+		tex := texture.TextureObj{}
+		if err := tex.LoadMemoryLayout(2, 2, data, l); err != nil {
+			t.Errorf(err.Error())
+			continue
+		}
+		if tex.GetID() == 0 {
+			t.Errorf("texture id is 0")
+		}
+	}
+
+	r.Release()
+	renderer.TerminateAll()
+}
+
+func TestFormat(t *testing.T) {
+	renderer.InitAll()
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf("failed to init renderer")
+	}
+	win, err := window.NewWindowObj(&window.WindowInitParam{SRGB: true})
+	r.AppendWindow(win)
+
+	albedo, _ := texture.NewTextureObj(&texture.TextureParam{
+		Format: texture.FormatSRGB8Alpha8,
+	})
+	if err := albedo.Load(TestTexturePNG); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if albedo.GetFormat() != texture.FormatSRGB8Alpha8 {
+		t.Errorf("format should be FormatSRGB8Alpha8")
+	}
+	if w, h := albedo.GetSize(); w <= 0 || h <= 0 {
+		t.Errorf("invalid texture size %vx%v", w, h)
+	}
+
+	shadow, _ := texture.NewTextureObj(&texture.TextureParam{
+		Format:      texture.FormatDepth24,
+		CompareFunc: gl.LEQUAL,
+	})
+	if err := shadow.Load(TestTexturePNG); err == nil {
+		t.Errorf("load image to depth texture should return error")
+	}
+	if err := shadow.Alloc(64, 64); err != nil {
+		t.Errorf(err.Error())
+	}
+	if shadow.GetID() == 0 {
+		t.Errorf("texture id is 0")
+	}
+
+	// Alloc allocates all the mipmap levels and deletes the old texture
+	target, _ := texture.NewTextureObj(&texture.TextureParam{
+		MinFilter: gl.LINEAR_MIPMAP_LINEAR,
+		Mipmap:    true,
+	})
+	if err := target.Alloc(64, 32); err != nil {
+		t.Fatalf(err.Error())
+	}
+	id := target.GetID()
+	var width int32
+	gl.BindTexture(gl.TEXTURE_2D, id)
+	gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 6, gl.TEXTURE_WIDTH, &width)
+	if width != 1 {
+		t.Errorf("the last mipmap level width %v should be 1", width)
+	}
+	if err := target.Alloc(16, 16); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if gl.IsTexture(id) {
+		t.Errorf("the old texture should be deleted by Alloc")
+	}
+	target.Release()
+
+	r.Release()
+	renderer.TerminateAll()
+}
+
+func TestSplitCubeImage(t *testing.T) {
+	// horizontal cross, each face is filled by its index
+	cells := [6][2]int{{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {3, 1}}
+	cross := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	for i, c := range cells {
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 2; x++ {
+				cross.Set(c[0]*2+x, c[1]*2+y, color.RGBA{uint8(i), 0, 0, 255})
+			}
+		}
+	}
+	faces, err := texture.SplitCubeImage(cross, texture.CubeLayoutAuto)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for i, face := range faces {
+		if face.Bounds().Dx() != 2 || face.Bounds().Dy() != 2 {
+			t.Errorf("invalid face size %v", face.Bounds())
+		}
+		if r, _, _, _ := face.At(1, 1).RGBA(); r>>8 != uint32(i) {
+			t.Errorf("face %d has value %d", i, r>>8)
+		}
+	}
+
+	// vertical cross, the -Z face is rotated by 180 degrees
+	vcross := image.NewRGBA(image.Rect(0, 0, 6, 8))
+	vcross.Set(2, 6, color.RGBA{255, 0, 0, 255})
+	faces, err = texture.SplitCubeImage(vcross, texture.CubeLayoutAuto)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if r, _, _, _ := faces[texture.CubeFaceNegativeZ].At(1, 1).RGBA(); r>>8 != 255 {
+		t.Errorf("-Z face should be rotated")
+	}
+
+	// equirectangular panorama of a constant color
+	pano := codec.NewRGBAF32(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			pano.SetFloat(x, y, [4]float32{2, 0.5, 0, 1})
+		}
+	}
+	faces, err = texture.SplitCubeImage(pano, texture.CubeLayoutAuto)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for _, face := range faces {
+		f, ok := face.(*codec.RGBAF32)
+		if !ok {
+			t.Fatalf("face of float image should be float image")
+		}
+		if v := f.FloatAt(2, 2); v[0] != 2 || v[1] != 0.5 {
+			t.Errorf("invalid face value %v", v)
+		}
+	}
+
+	if _, err := texture.SplitCubeImage(image.NewRGBA(image.Rect(0, 0, 5, 5)),
+		texture.CubeLayoutAuto); err == nil {
+		t.Errorf("unknown layout should return error")
+	}
+}
+
+func TestLoadCube(t *testing.T) {
+	renderer.InitAll()
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf("failed to init renderer")
+	}
+	win, err := window.NewWindowObj(&window.WindowInitParam{})
+	r.AppendWindow(win)
+
+	cube, err := texture.NewCubeTextureObj(nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	var tex aperture.Texture = cube
+	data := make([]byte, 8*6*4)
+	if err := tex.LoadMemory(8, 6, &data); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if tex.GetID() == 0 || cube.GetSize() != 2 {
+		t.Errorf("invalid cube map texture")
+	}
+	if cube.GetTarget() != gl.TEXTURE_CUBE_MAP {
+		t.Errorf("target should be gl.TEXTURE_CUBE_MAP")
+	}
+
+	faces, _ := texture.NewCubeTextureObj(nil)
+	err = faces.LoadFaces([6]string{TestTexturePNG, TestTexturePNG,
+		TestTexturePNG, TestTexturePNG, TestTexturePNG, TestTexturePNG})
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if faces.GetSize() != 20 {
+		t.Errorf("face size should be 20")
+	}
+
+	r.Release()
+	renderer.TerminateAll()
+}
+
+func TestLoadLayered(t *testing.T) {
+	array := texture.TextureArrayObj{}
+	data := make([]byte, 2*2*4*3)
+	if err := array.LoadMemory(2, 2, &data); err == nil {
+		t.Errorf("texture without target should return error")
+	}
+
+	renderer.InitAll()
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf("failed to init renderer")
+	}
+	win, err := window.NewWindowObj(&window.WindowInitParam{})
+	r.AppendWindow(win)
+
+	blocks, err := texture.NewTextureArrayObj(&texture.TextureParam{
+		MinFilter: gl.NEAREST_MIPMAP_LINEAR,
+		MagFilter: gl.NEAREST,
+		WrapS:     gl.REPEAT,
+		WrapT:     gl.REPEAT,
+		Mipmap:    true,
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	var tex aperture.Texture = blocks
+	err = blocks.LoadFiles([]string{TestTextureJPG, TestTexturePNG})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if tex.GetID() == 0 || blocks.GetTarget() != gl.TEXTURE_2D_ARRAY {
+		t.Errorf("invalid array texture")
+	}
+	if w, h, d := blocks.GetSize(); w != 20 || h != 20 || d != 2 {
+		t.Errorf("invalid array texture size %vx%vx%v", w, h, d)
+	}
+
+	volume, _ := texture.NewTexture3DObj(nil)
+	if err := volume.LoadMemory(2, 2, &data); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if w, h, d := volume.GetSize(); w != 2 || h != 2 || d != 3 {
+		t.Errorf("invalid 3D texture size %vx%vx%v", w, h, d)
+	}
+	if err := volume.LoadMemoryLayout(2, 2, 4, data, texture.LayoutRGBA32F); err == nil {
+		t.Errorf("insufficient data should return error")
+	}
+	if err := volume.LoadMemoryLayout(4, 2, 3, data, texture.LayoutR8.WithStride(8)); err != nil {
+		t.Errorf(err.Error())
+	}
+
+	r.Release()
+	renderer.TerminateAll()
+}
+
+func TestLoader(t *testing.T) {
+	renderer.InitAll()
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf("failed to init renderer")
+	}
+	win, err := window.NewWindowObj(&window.WindowInitParam{})
+	r.AppendWindow(win)
+
+	loader, err := texture.NewLoaderObj(&texture.LoaderInitParam{Workers: 2})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	futures := []*texture.FutureObj{
+		loader.Load(TestTextureJPG, nil),
+		loader.Load(TestTexturePNG, &texture.TextureParam{Mipmap: true}),
+		loader.Load("test/not_found.png", nil),
+	}
+	if futures[0].GetTexture() != loader.GetPlaceholder() {
+		t.Errorf("texture should be placeholder while loading")
+	}
+	for !loader.IsDone() {
+		loader.Update()
+		time.Sleep(time.Millisecond)
+	}
+	if loader.GetProgress() != 1 {
+		t.Errorf("progress should be 1")
+	}
+	for _, f := range futures[:2] {
+		if f.GetError() != nil {
+			t.Errorf(f.GetError().Error())
+		}
+		if !f.IsDone() || f.GetTextureObj() == nil || f.GetTexture().GetID() == 0 {
+			t.Errorf("texture %q not loaded", f.GetFileName())
+		}
+	}
+	if futures[2].GetError() == nil {
+		t.Errorf("load not exist file should return error")
+	}
+	if futures[2].GetTexture() != loader.GetPlaceholder() {
+		t.Errorf("texture failed to load should be placeholder")
+	}
+	loader.Release()
+
+	// the futures not uploaded are finished with error after release
+	loader, err = texture.NewLoaderObj(&texture.LoaderInitParam{Workers: 1})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	// the futures waiting for the worker are cancelled without decoding
+	futures = nil
+	for i := 0; i < 8; i++ {
+		futures = append(futures, loader.Load(TestTextureJPG, nil))
+	}
+	loader.Release()
+	deadline := time.Now().Add(5 * time.Second)
+	for !loader.IsDone() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if loader.GetProgress() != 1 {
+		t.Errorf("progress should be 1 after release")
+	}
+	for _, f := range futures {
+		if !f.IsDone() || f.GetError() == nil || f.GetTextureObj() != nil {
+			t.Errorf("texture %q should be finished with error", f.GetFileName())
+		}
+	}
+
+	r.Release()
+	renderer.TerminateAll()
+}
+
+func TestCache(t *testing.T) {
+	renderer.InitAll()
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf("failed to init renderer")
+	}
+	win, err := window.NewWindowObj(&window.WindowInitParam{})
+	r.AppendWindow(win)
+
+	cache := win.GetTextureCache()
+	a, err := cache.Load(TestTexturePNG, nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	// the default parameters share the same cached texture
+	b, err := cache.Load("./"+TestTexturePNG, &texture.TextureParam{MinFilter: gl.LINEAR})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if a != b || cache.GetRefCount(a) != 2 {
+		t.Errorf("texture should be shared")
+	}
+	c, err := cache.Load(TestTexturePNG, &texture.TextureParam{MinFilter: gl.NEAREST})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if c == a || cache.GetCount() != 2 {
+		t.Errorf("texture of different parameters should not be shared")
+	}
+	if _, err := cache.Load("test/not_found.png", nil); err == nil {
+		t.Errorf("load not exist file should return error")
+	}
+
+	a.Release()
+	if a.GetID() == 0 || cache.GetRefCount(a) != 1 {
+		t.Errorf("texture should not be deleted before the last release")
+	}
+	b.Release()
+	if a.GetID() != 0 || cache.GetRefCount(a) != 0 {
+		t.Errorf("texture should be deleted after the last release")
+	}
+
+	tex := texture.TextureObj{}
+	if err := tex.Load(TestTextureJPG); err != nil {
+		t.Fatalf(err.Error())
+	}
+	tex.Release()
+	if tex.GetID() != 0 {
+		t.Errorf("texture id should be 0 after released")
+	}
+
+	r.Release()
+	renderer.TerminateAll()
+}
+
+func TestDownload(t *testing.T) {
+	tex := texture.TextureObj{}
+	if _, err := tex.Download(); err == nil {
+		t.Errorf("download not loaded texture should return error")
+	}
+	if err := texture.SaveImage("test/out.bmp", image.NewRGBA(image.Rect(0, 0, 1, 1))); err == nil {
+		t.Errorf("unsupported extension should return error")
+	}
+
+	renderer.InitAll()
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		Name:      "TestRenderer",
+		Resizable: false,
+		Visiable:  false,
+	})
+	if err != nil {
+		t.Fatalf("failed to init renderer")
+	}
+	win, err := window.NewWindowObj(&window.WindowInitParam{})
+	r.AppendWindow(win)
+
+	data := []byte{
+		255, 0, 0, 255, 0, 255, 0, 255,
+		0, 0, 255, 255, 255, 255, 255, 128,
+	}
+	if err := tex.LoadMemory(2, 2, &data); err != nil {
+		t.Fatalf(err.Error())
+	}
+	img, err := tex.Download()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		t.Fatalf("RGBA8 texture should be downloaded as *image.RGBA")
+	}
+	for i := range data {
+		if rgba.Pix[i] != data[i] {
+			t.Errorf("downloaded pixels %v not equal to %v", rgba.Pix, data)
+			break
+		}
+	}
+
+	hdr, _ := texture.NewTextureObj(&texture.TextureParam{Format: texture.FormatRGBA16F})
+	if err := hdr.LoadMemory(2, 2, &data); err != nil {
+		t.Fatalf(err.Error())
+	}
+	img, err = hdr.Download()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if f, ok := img.(*codec.RGBAF32); !ok || f.FloatAt(0, 0)[0] != 1 {
+		t.Errorf("float texture should be downloaded as *codec.RGBAF32")
+	}
+
+	depth, _ := texture.NewTextureObj(&texture.TextureParam{Format: texture.FormatDepth24})
+	depth.Alloc(4, 4)
+	if img, err := depth.Download(); err != nil {
+		t.Errorf(err.Error())
+	} else if _, ok := img.(*image.Gray16); !ok {
+		t.Errorf("depth texture should be downloaded as *image.Gray16")
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"out.png", "out.jpg", "out.hdr"} {
+		if err := hdr.Save(filepath.Join(dir, name)); err != nil {
+			t.Errorf(err.Error())
+		}
+	}
+
+	r.Release()
+	renderer.TerminateAll()
+}
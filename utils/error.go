@@ -10,4 +10,6 @@ var (
 	ErrReInitialize     = errors.New("re-initialize the initialized resouce")
 	ErrEmptyFile        = errors.New("file is empty")
 	ErrPositionExceed   = errors.New("position exceeded of maximum value")
+
+	ErrIncompleteFramebuffer = errors.New("framebuffer is incomplete")
 )
@@ -0,0 +1,198 @@
+package window
+
+import (
+	"fmt"
+	"math"
+
+	ap "github.com/STARRY-S/aperture"
+	"github.com/STARRY-S/aperture/utils"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// ViewportObj implements the Viewport interface, it renders the camera to
+// a rectangle of the window for split-screen, picture-in-picture and the
+// editor quad view.
+type ViewportObj struct {
+	name string
+
+	// x, y, width and height are normalized to the window size, the origin
+	// is the bottom-left corner.
+	x      float32
+	y      float32
+	width  float32
+	height float32
+
+	camera     ap.Camera
+	clearColor [4]float32
+	noClear    bool
+	renderFunc ap.ViewportRenderFunc
+}
+
+// ViewportInitParam is used for customize the parameters when init viewport.
+type ViewportInitParam struct {
+	Name string
+	// X, Y, Width and Height are the rectangle normalized to the window
+	// size, the origin is the bottom-left corner. The default is the full
+	// window.
+	X      float32
+	Y      float32
+	Width  float32
+	Height float32
+
+	Camera ap.Camera
+	Func   ap.ViewportRenderFunc
+
+	// ClearColor is the RGBA color to clear the viewport.
+	ClearColor [4]float32
+	// NoClearColor keeps the color of the window under the viewport, for
+	// overlays drawn over other viewports. The depth is always cleared.
+	NoClearColor bool
+}
+
+func (v *ViewportObj) Init(initParam interface{}) error {
+	if v == nil {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidPointer)
+	}
+
+	if initParam == nil {
+		initParam = ViewportInitParam{}
+	}
+	p, ok := initParam.(ViewportInitParam)
+	if !ok {
+		return fmt.Errorf("Init: %w", utils.ErrInvalidDataType)
+	}
+
+	if p.Width == 0 && p.Height == 0 {
+		p.Width, p.Height = 1, 1
+	}
+	if p.Width <= 0 || p.Height <= 0 {
+		return fmt.Errorf("Init: invalid viewport size %vx%v: %w",
+			p.Width, p.Height, utils.ErrInvalidParameter)
+	}
+
+	v.name = p.Name
+	v.SetRect(p.X, p.Y, p.Width, p.Height)
+	v.camera = p.Camera
+	v.renderFunc = p.Func
+	v.clearColor = p.ClearColor
+	v.noClear = p.NoClearColor
+	return nil
+}
+
+// SetRect sets the rectangle normalized to the window size, the origin is
+// the bottom-left corner of the window.
+func (v *ViewportObj) SetRect(x, y, width, height float32) {
+	v.x, v.y, v.width, v.height = x, y, width, height
+}
+
+func (v *ViewportObj) GetRect() (x, y, width, height float32) {
+	return v.x, v.y, v.width, v.height
+}
+
+// GetPixelRect gets the rectangle in pixels of the framebuffer size, the
+// edges are rounded so the adjacent viewports have no gap or overlap.
+func (v *ViewportObj) GetPixelRect(width, height int) (x, y, w, h int32) {
+	round := func(f float32, size int) int32 {
+		return int32(math.Round(float64(f) * float64(size)))
+	}
+	x0, x1 := round(v.x, width), round(v.x+v.width, width)
+	y0, y1 := round(v.y, height), round(v.y+v.height, height)
+	return x0, y0, x1 - x0, y1 - y0
+}
+
+// Contains checks whether the point normalized to the window size is
+// inside the viewport, the origin is the bottom-left corner.
+func (v *ViewportObj) Contains(x, y float32) bool {
+	return x >= v.x && x < v.x+v.width && y >= v.y && y < v.y+v.height
+}
+
+// SetCamera sets the camera of the viewport, the aspect ratio of the
+// camera is set to the viewport size when rendering.
+func (v *ViewportObj) SetCamera(c ap.Camera) {
+	v.camera = c
+}
+
+func (v *ViewportObj) GetCamera() ap.Camera {
+	return v.camera
+}
+
+// SetClearColor sets the RGBA color to clear the viewport and enables the
+// color clearing.
+func (v *ViewportObj) SetClearColor(rgba [4]float32) {
+	v.clearColor = rgba
+	v.noClear = false
+}
+
+func (v *ViewportObj) GetClearColor() [4]float32 {
+	return v.clearColor
+}
+
+// SetClearColorEnabled sets whether the color of the viewport is cleared,
+// the depth is always cleared.
+func (v *ViewportObj) SetClearColorEnabled(b bool) {
+	v.noClear = !b
+}
+
+func (v *ViewportObj) GetClearColorEnabled() bool {
+	return !v.noClear
+}
+
+func (v *ViewportObj) SetRenderFunc(f ap.ViewportRenderFunc) {
+	v.renderFunc = f
+}
+
+func (v *ViewportObj) GetRenderFunc() ap.ViewportRenderFunc {
+	return v.renderFunc
+}
+
+// Render sets the viewport and the scissor box in the framebuffer of the
+// size, clears it and calls the render function with the camera,
+// this method requires an active OpenGL context.
+func (v *ViewportObj) Render(width, height int) {
+	x, y, w, h := v.GetPixelRect(width, height)
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	gl.Viewport(x, y, w, h)
+	gl.Enable(gl.SCISSOR_TEST)
+	gl.Scissor(x, y, w, h)
+	var mask uint32 = gl.DEPTH_BUFFER_BIT
+	var lastColor [4]float32
+	if !v.noClear {
+		gl.GetFloatv(gl.COLOR_CLEAR_VALUE, &lastColor[0])
+		gl.ClearColor(v.clearColor[0], v.clearColor[1], v.clearColor[2], v.clearColor[3])
+		mask |= gl.COLOR_BUFFER_BIT
+	}
+	gl.Clear(mask)
+	if !v.noClear {
+		gl.ClearColor(lastColor[0], lastColor[1], lastColor[2], lastColor[3])
+	}
+
+	if v.camera != nil {
+		v.camera.SetAspect(float32(w) / float32(h))
+	}
+	if v.renderFunc != nil {
+		v.renderFunc(v.camera)
+	}
+	gl.Disable(gl.SCISSOR_TEST)
+}
+
+func (v *ViewportObj) GetName() string {
+	return v.name
+}
+
+func (v *ViewportObj) SetName(name string) {
+	v.name = name
+}
+
+func NewViewportObj(p *ViewportInitParam) (*ViewportObj, error) {
+	v := ViewportObj{}
+	if p == nil {
+		p = &ViewportInitParam{}
+	}
+	if err := v.Init(*p); err != nil {
+		return nil, fmt.Errorf("NewViewportObj: %w", err)
+	}
+	return &v, nil
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	ap "github.com/STARRY-S/aperture"
+	"github.com/STARRY-S/aperture/geometry"
+	"github.com/STARRY-S/aperture/texture"
 	"github.com/STARRY-S/aperture/utils"
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -32,6 +34,13 @@ type WindowObj struct {
 
 	shaders  []ap.Shader
 	textures []ap.Texture
+	// textureCache is the texture cache of the OpenGL context of the window
+	textureCache *texture.CacheObj
+
+	camera    ap.Camera
+	viewports []ap.Viewport
+	// viewDistance is the far plane of the cameras, set by the renderer
+	viewDistance float32
 
 	glfwWindow *glfw.Window
 
@@ -52,6 +61,10 @@ type WindowInitParam struct {
 
 	// BackgroundColor is the RGBA value of the parameter of glClearColor func.
 	BackgroundColor [4]float32
+
+	// SRGB enables the sRGB capable default framebuffer (GL_FRAMEBUFFER_SRGB),
+	// the linear color written by shaders is converted to sRGB on output.
+	SRGB bool
 }
 
 const (
@@ -93,6 +106,12 @@ func (w *WindowObj) Init(initParam interface{}) error {
 		p.Func = defaultRenderFunc
 	}
 
+	if p.SRGB {
+		glfw.WindowHint(glfw.SRGBCapable, glfw.True)
+	} else {
+		glfw.WindowHint(glfw.SRGBCapable, glfw.False)
+	}
+
 	// Generate GLFW Window.
 	monitors := glfw.GetMonitors()
 	if len(monitors) != 0 {
@@ -164,8 +183,14 @@ func (w *WindowObj) Init(initParam interface{}) error {
 	if err := gl.Init(); err != nil {
 		return fmt.Errorf("Init: %w", err)
 	}
+	if p.SRGB {
+		gl.Enable(gl.FRAMEBUFFER_SRGB)
+	}
+	w.textureCache, _ = texture.NewCacheObj()
+	w.glfwWindow.SetFramebufferSizeCallback(w.framebufferSizeCallback)
 
 	w.initialized = true
+	w.applyAspect()
 
 	return nil
 }
@@ -285,6 +310,142 @@ func (w *WindowObj) GetTextureNum() int {
 	return len(w.textures)
 }
 
+// SetCamera sets the camera of the window, the aspect ratio of the camera
+// is set to the framebuffer size and updated when the window resized, the
+// far plane is set to the view distance of the window. The aspect ratio of
+// the camera set before Init is set in Init.
+func (w *WindowObj) SetCamera(c ap.Camera) {
+	w.camera = c
+	w.applyViewDistance(c)
+	w.applyAspect()
+}
+
+// applyAspect sets the aspect ratio of the camera to the framebuffer size.
+func (w *WindowObj) applyAspect() {
+	if w.camera == nil || !w.initialized {
+		return
+	}
+	width, height := w.glfwWindow.GetFramebufferSize()
+	if width > 0 && height > 0 {
+		w.camera.SetAspect(float32(width) / float32(height))
+	}
+}
+
+func (w *WindowObj) GetCamera() ap.Camera {
+	return w.camera
+}
+
+// AppendViewport adds the viewport to the window, the viewports are
+// rendered in the adding order. If the window has viewports, the render
+// function of the window is not called. The far plane of the camera of
+// the viewport is set to the view distance of the window.
+func (w *WindowObj) AppendViewport(v ap.Viewport) {
+	if v == nil {
+		return
+	}
+	w.viewports = append(w.viewports, v)
+	w.applyViewDistance(v.GetCamera())
+}
+
+func (w *WindowObj) GetViewport(pos int) ap.Viewport {
+	return w.viewports[pos]
+}
+
+func (w *WindowObj) GetViewportNum() int {
+	return len(w.viewports)
+}
+
+// RemoveViewport removes the viewport at the position, the invalid
+// position is ignored.
+func (w *WindowObj) RemoveViewport(pos int) {
+	if pos < 0 || pos >= len(w.viewports) {
+		return
+	}
+	w.viewports = append(w.viewports[:pos], w.viewports[pos+1:]...)
+}
+
+// SetViewDistance sets the far plane of the camera of the window and the
+// cameras of the viewports, the cameras set later are also applied.
+// The distance less than or equal to 0 keeps the far plane of the cameras.
+func (w *WindowObj) SetViewDistance(d float32) {
+	w.viewDistance = d
+	w.applyViewDistance(w.camera)
+	for _, v := range w.viewports {
+		w.applyViewDistance(v.GetCamera())
+	}
+}
+
+func (w *WindowObj) GetViewDistance() float32 {
+	return w.viewDistance
+}
+
+// applyViewDistance sets the far plane of the camera to the view distance.
+func (w *WindowObj) applyViewDistance(c ap.Camera) {
+	if c == nil || w.viewDistance <= 0 {
+		return
+	}
+	c.SetFar(w.viewDistance)
+}
+
+// GetCursorRay gets the world ray through the cursor, it is used to pick
+// the objects under the cursor. If the window has viewports, the camera of
+// the top-most viewport under the cursor is used, otherwise the camera of
+// the window is used.
+func (w *WindowObj) GetCursorRay() (geometry.Ray, error) {
+	if !w.initialized {
+		return geometry.Ray{}, fmt.Errorf("GetCursorRay: window not initialized: %w",
+			utils.ErrInvalidPointer)
+	}
+	x, y := w.glfwWindow.GetCursorPos()
+	width, height := w.glfwWindow.GetSize()
+	if width <= 0 || height <= 0 {
+		return geometry.Ray{}, fmt.Errorf("GetCursorRay: %w", utils.ErrInvalidParameter)
+	}
+	// the normalized cursor position with the bottom-left origin
+	nx := float32(x) / float32(width)
+	ny := 1 - float32(y)/float32(height)
+	for i := len(w.viewports) - 1; i >= 0; i-- {
+		v := w.viewports[i]
+		vx, vy, vw, vh := v.GetRect()
+		if v.GetCamera() == nil || nx < vx || nx >= vx+vw || ny < vy || ny >= vy+vh {
+			continue
+		}
+		vp := v.GetCamera().GetViewProjectionMatrix()
+		ray, err := geometry.NDCRay(2*(nx-vx)/vw-1, 2*(ny-vy)/vh-1, vp)
+		if err != nil {
+			return geometry.Ray{}, fmt.Errorf("GetCursorRay: %w", err)
+		}
+		return ray, nil
+	}
+
+	if w.camera == nil {
+		return geometry.Ray{}, fmt.Errorf("GetCursorRay: camera not set: %w", utils.ErrInvalidPointer)
+	}
+	ray, err := geometry.ScreenRay(float32(x), float32(y), width, height, w.camera)
+	if err != nil {
+		return geometry.Ray{}, fmt.Errorf("GetCursorRay: %w", err)
+	}
+	return ray, nil
+}
+
+// framebufferSizeCallback updates the viewport and the aspect ratio of the
+// camera when the framebuffer resized.
+func (w *WindowObj) framebufferSizeCallback(_ *glfw.Window, width, height int) {
+	// the size is 0 when the window minimized
+	if width <= 0 || height <= 0 {
+		return
+	}
+	current := glfw.GetCurrentContext()
+	w.glfwWindow.MakeContextCurrent()
+	gl.Viewport(0, 0, int32(width), int32(height))
+	if current != nil && current != w.glfwWindow {
+		current.MakeContextCurrent()
+	}
+	if w.camera != nil {
+		w.camera.SetAspect(float32(width) / float32(height))
+	}
+}
+
 func (w *WindowObj) Flush() {
 	// update fps
 	w.frameCount++
@@ -300,18 +461,32 @@ func (w *WindowObj) Flush() {
 		w.backgroundColor[2],
 		w.backgroundColor[3])
 
-	// main render function
-	if w.renderFunc == nil {
-		logrus.Warnln("Flush: render function is nil, set back to default.")
-		w.renderFunc = defaultRenderFunc
+	if len(w.viewports) > 0 {
+		w.renderViewports()
+	} else {
+		// main render function
+		if w.renderFunc == nil {
+			logrus.Warnln("Flush: render function is nil, set back to default.")
+			w.renderFunc = defaultRenderFunc
+		}
+		w.renderFunc()
 	}
-	w.renderFunc()
 
 	// V-Sync
 	w.glfwWindow.SwapBuffers()
 	glfw.PollEvents()
 }
 
+// renderViewports renders the viewports and restores the full window
+// viewport.
+func (w *WindowObj) renderViewports() {
+	width, height := w.glfwWindow.GetFramebufferSize()
+	for _, v := range w.viewports {
+		v.Render(width, height)
+	}
+	gl.Viewport(0, 0, int32(width), int32(height))
+}
+
 func (w *WindowObj) Close() {
 	w.glfwWindow.SetShouldClose(true)
 	w.SetVisible(false)
@@ -323,11 +498,23 @@ func (w *WindowObj) IsClosed() bool {
 }
 
 func (w *WindowObj) Destroy() {
+	if w.textureCache != nil {
+		// delete the cached textures in the context of this window
+		w.glfwWindow.MakeContextCurrent()
+		w.textureCache.Clear()
+		w.textureCache = nil
+	}
 	w.glfwWindow.Destroy()
 	w.glfwWindow = nil
 	w.initialized = false
 }
 
+// GetTextureCache gets the texture cache of the OpenGL context of the
+// window, the textures are not shared between windows.
+func (w *WindowObj) GetTextureCache() *texture.CacheObj {
+	return w.textureCache
+}
+
 func (w *WindowObj) GetName() string {
 	return w.name
 }
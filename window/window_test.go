@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/STARRY-S/aperture"
+	"github.com/STARRY-S/aperture/camera"
 	"github.com/STARRY-S/aperture/renderer"
 	"github.com/stretchr/testify/assert"
 )
@@ -73,3 +74,135 @@ func TestSetterGetter(t *testing.T) {
 	win.Destroy()
 	renderer.TerminateAll()
 }
+
+func TestCamera(t *testing.T) {
+	renderer.InitAll()
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{
+		ViewDistance: 256,
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	win, err := NewWindowObj(&WindowInitParam{Width: 400, Height: 200})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	r.AppendWindow(win)
+
+	cam, _ := camera.NewCameraObj()
+	win.SetCamera(cam)
+	assert.Equal(t, aperture.Camera(cam), win.GetCamera())
+	// the far plane is set to the view distance of the renderer
+	assert.Equal(t, float32(256), win.GetViewDistance())
+	assert.Equal(t, float32(256), cam.GetFar())
+	r.SetViewDistance(512)
+	assert.Equal(t, float32(512), cam.GetFar())
+	r.SetViewDistance(256)
+	width, height := win.glfwWindow.GetFramebufferSize()
+	assert.Equal(t, float32(width)/float32(height), cam.GetAspect())
+
+	// the aspect ratio follows the framebuffer size
+	win.framebufferSizeCallback(win.glfwWindow, 300, 300)
+	assert.Equal(t, float32(1), cam.GetAspect())
+
+	other, _ := camera.NewCameraObj()
+	r.ApplyViewDistance(other)
+	assert.Equal(t, float32(256), other.GetFar())
+
+	_, err = win.GetCursorRay()
+	assert.Nil(t, err)
+
+	// the aspect ratio of the camera set before Init is set in Init
+	early, _ := camera.NewCameraObj()
+	pending := &WindowObj{}
+	pending.SetCamera(early)
+	_, err = pending.GetCursorRay()
+	assert.NotNil(t, err)
+	if err := pending.Init(WindowInitParam{Width: 400, Height: 200}); err != nil {
+		t.Fatalf(err.Error())
+	}
+	width, height = pending.glfwWindow.GetFramebufferSize()
+	assert.Equal(t, float32(width)/float32(height), early.GetAspect())
+	pending.Destroy()
+	win.MakeContextCurrent()
+
+	r.Release()
+	renderer.TerminateAll()
+}
+
+func TestViewport(t *testing.T) {
+	var _ aperture.Viewport = &ViewportObj{}
+
+	v, err := NewViewportObj(nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	x, y, w, h := v.GetRect()
+	assert.Equal(t, []float32{0, 0, 1, 1}, []float32{x, y, w, h})
+	_, err = NewViewportObj(&ViewportInitParam{Width: -1, Height: 1})
+	assert.NotNil(t, err)
+
+	// the adjacent viewports have no gap
+	left, _ := NewViewportObj(&ViewportInitParam{Width: 1.0 / 3, Height: 1})
+	right, _ := NewViewportObj(&ViewportInitParam{X: 1.0 / 3, Width: 2.0 / 3, Height: 1})
+	lx, _, lw, _ := left.GetPixelRect(100, 50)
+	rx, _, rw, _ := right.GetPixelRect(100, 50)
+	assert.Equal(t, lx+lw, rx)
+	assert.Equal(t, int32(100), lw+rw)
+	assert.True(t, right.Contains(0.5, 0.5))
+	assert.False(t, right.Contains(0.2, 0.5))
+
+	v.SetClearColorEnabled(false)
+	assert.False(t, v.GetClearColorEnabled())
+	v.SetClearColor([4]float32{1, 0, 0, 1})
+	assert.True(t, v.GetClearColorEnabled())
+
+	renderer.InitAll()
+	r, err := renderer.NewRendererObj(&renderer.RendererInitParam{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	win, err := NewWindowObj(&WindowInitParam{Width: 400, Height: 200})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	r.AppendWindow(win)
+
+	// split-screen with the picture-in-picture overlay
+	cams := make([]*camera.CameraObj, 3)
+	called := make([]int, 3)
+	rects := [][4]float32{{0, 0, 0.5, 1}, {0.5, 0, 0.5, 1}, {0.75, 0.75, 0.25, 0.25}}
+	for i := range cams {
+		i := i
+		cams[i], _ = camera.NewCameraObj()
+		vp, err := NewViewportObj(&ViewportInitParam{
+			X: rects[i][0], Y: rects[i][1], Width: rects[i][2], Height: rects[i][3],
+			Camera: cams[i],
+			Func: func(c aperture.Camera) {
+				assert.Equal(t, aperture.Camera(cams[i]), c)
+				called[i]++
+			},
+		})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		win.AppendViewport(vp)
+	}
+	assert.Equal(t, 3, win.GetViewportNum())
+	win.Flush()
+	assert.Equal(t, []int{1, 1, 1}, called)
+	width, height := win.glfwWindow.GetFramebufferSize()
+	assert.InDelta(t, float32(width)/2/float32(height), cams[0].GetAspect(), 1e-2)
+
+	_, err = win.GetCursorRay()
+	assert.Nil(t, err)
+
+	win.RemoveViewport(2)
+	win.RemoveViewport(5)
+	assert.Equal(t, 2, win.GetViewportNum())
+	win.Flush()
+	assert.Equal(t, []int{2, 2, 1}, called)
+
+	r.Release()
+	renderer.TerminateAll()
+}